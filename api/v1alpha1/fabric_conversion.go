@@ -0,0 +1,117 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"encoding/json"
+	"reflect"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	"github.com/DanielXLee/cluster-fabric-operator/api/v1alpha2"
+)
+
+var _ conversion.Convertible = &Fabric{}
+
+// ConvertTo converts this Fabric to the hub version, v1alpha1 can't tell a disabled feature from an unset one so
+// its feature switches are always set explicitly in v1alpha2
+func (src *Fabric) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1alpha2.Fabric)
+	dst.ObjectMeta = src.ObjectMeta
+	if err := convertViaJSON(&src.Spec, &dst.Spec); err != nil {
+		return err
+	}
+	if err := convertViaJSON(&src.Status, &dst.Status); err != nil {
+		return err
+	}
+
+	// A v1alpha1 fabric always carries a broker configuration, the one the API server defaults when the fabric has
+	// none: without roles stated, a default broker configuration is an unset one and the broker is not deployed
+	if len(src.Spec.Roles) == 0 && isDefaultBrokerConfig(&src.Spec.BrokerConfig) {
+		dst.Spec.BrokerConfig = nil
+	} else {
+		dst.Spec.BrokerConfig.ServiceDiscoveryEnabled = boolPtr(src.Spec.BrokerConfig.ServiceDiscoveryEnabled)
+	}
+	convertJoinConfigTo(&src.Spec.JoinConfig, &dst.Spec.JoinConfig)
+	for i := range src.Spec.ManagedClusters {
+		convertJoinConfigTo(&src.Spec.ManagedClusters[i].JoinConfig, &dst.Spec.ManagedClusters[i].JoinConfig)
+	}
+	return nil
+}
+
+// ConvertFrom converts the hub version to this Fabric, an unset feature switch is the default of v1alpha1
+func (dst *Fabric) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1alpha2.Fabric)
+	dst.ObjectMeta = src.ObjectMeta
+	if err := convertViaJSON(&src.Spec, &dst.Spec); err != nil {
+		return err
+	}
+	if err := convertViaJSON(&src.Status, &dst.Status); err != nil {
+		return err
+	}
+
+	if src.Spec.BrokerConfig != nil {
+		dst.Spec.BrokerConfig.ServiceDiscoveryEnabled = v1alpha2.IsEnabled(src.Spec.BrokerConfig.ServiceDiscoveryEnabled)
+	}
+	convertJoinConfigFrom(&src.Spec.JoinConfig, &dst.Spec.JoinConfig)
+	for i := range src.Spec.ManagedClusters {
+		convertJoinConfigFrom(&src.Spec.ManagedClusters[i].JoinConfig, &dst.Spec.ManagedClusters[i].JoinConfig)
+	}
+	return nil
+}
+
+// isDefaultBrokerConfig tells whether the broker configuration is an empty one, or an empty one the API server
+// defaulted
+func isDefaultBrokerConfig(brokerConfig *BrokerConfig) bool {
+	defaulted := BrokerConfig{
+		ServiceDiscoveryEnabled:      true,
+		GlobalnetCIDRRange:           "242.0.0.0/8",
+		DefaultGlobalnetClusterSize:  65336,
+		GlobalCIDRReleaseGracePeriod: &metav1.Duration{Duration: time.Hour},
+	}
+	return reflect.DeepEqual(*brokerConfig, BrokerConfig{}) || reflect.DeepEqual(*brokerConfig, defaulted)
+}
+
+func convertJoinConfigTo(src *JoinConfig, dst *v1alpha2.JoinConfig) {
+	dst.NatTraversal = boolPtr(src.NatTraversal)
+	dst.GlobalnetEnabled = boolPtr(src.GlobalnetEnabled)
+	dst.LabelGateway = boolPtr(src.LabelGateway)
+	dst.HealthCheckEnable = boolPtr(src.HealthCheckEnable)
+}
+
+func convertJoinConfigFrom(src *v1alpha2.JoinConfig, dst *JoinConfig) {
+	dst.NatTraversal = v1alpha2.IsEnabled(src.NatTraversal)
+	dst.GlobalnetEnabled = v1alpha2.IsEnabled(src.GlobalnetEnabled)
+	dst.LabelGateway = v1alpha2.IsEnabled(src.LabelGateway)
+	dst.HealthCheckEnable = v1alpha2.IsEnabled(src.HealthCheckEnable)
+}
+
+// convertViaJSON converts the parts of the Fabric the versions share, only the feature switches differ between
+// them and those are converted on their own
+func convertViaJSON(src, dst interface{}) error {
+	data, err := json.Marshal(src)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dst)
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/DanielXLee/cluster-fabric-operator/api/v1alpha2"
+)
+
+var _ = Describe("Fabric conversion", func() {
+	It("Should keep the disabled features disabled in v1alpha2", func() {
+		fabric := &Fabric{
+			Spec: FabricSpec{
+				BrokerConfig: BrokerConfig{ServiceDiscoveryEnabled: false, GlobalnetEnable: true},
+				JoinConfig:   JoinConfig{ClusterID: "cluster1", NatTraversal: false, LabelGateway: true, NattPort: 4500},
+				ManagedClusters: []ManagedCluster{
+					{JoinConfig: JoinConfig{ClusterID: "cluster2", HealthCheckEnable: false}},
+				},
+			},
+		}
+		hub := &v1alpha2.Fabric{}
+		Expect(fabric.ConvertTo(hub)).To(Succeed())
+		Expect(hub.Spec.BrokerConfig.ServiceDiscoveryEnabled).To(Equal(boolPtr(false)))
+		Expect(hub.Spec.BrokerConfig.GlobalnetEnable).To(BeTrue())
+		Expect(hub.Spec.JoinConfig.NatTraversal).To(Equal(boolPtr(false)))
+		Expect(hub.Spec.JoinConfig.LabelGateway).To(Equal(boolPtr(true)))
+		Expect(hub.Spec.JoinConfig.NattPort).To(Equal(4500))
+		Expect(hub.Spec.ManagedClusters[0].JoinConfig.HealthCheckEnable).To(Equal(boolPtr(false)))
+
+		converted := &Fabric{}
+		Expect(converted.ConvertFrom(hub)).To(Succeed())
+		Expect(converted).To(Equal(fabric))
+	})
+
+	It("Should enable the unset features in v1alpha1", func() {
+		disabled := false
+		hub := &v1alpha2.Fabric{
+			Spec: v1alpha2.FabricSpec{
+				BrokerConfig: &v1alpha2.BrokerConfig{},
+				JoinConfig:   v1alpha2.JoinConfig{ClusterID: "cluster1", GlobalnetEnabled: &disabled},
+			},
+		}
+		fabric := &Fabric{}
+		Expect(fabric.ConvertFrom(hub)).To(Succeed())
+		Expect(fabric.Spec.BrokerConfig.ServiceDiscoveryEnabled).To(BeTrue())
+		Expect(fabric.Spec.JoinConfig.NatTraversal).To(BeTrue())
+		Expect(fabric.Spec.JoinConfig.GlobalnetEnabled).To(BeFalse())
+	})
+
+	It("Should leave the broker configuration of a joining fabric unset", func() {
+		fabric := &Fabric{
+			Spec: FabricSpec{JoinConfig: JoinConfig{ClusterID: "cluster1"}},
+		}
+		hub := &v1alpha2.Fabric{}
+		Expect(fabric.ConvertTo(hub)).To(Succeed())
+		Expect(hub.Spec.BrokerConfig).To(BeNil())
+
+		converted := &Fabric{}
+		Expect(converted.ConvertFrom(hub)).To(Succeed())
+		Expect(converted.Spec.BrokerConfig).To(Equal(BrokerConfig{}))
+	})
+
+	It("Should only join a fabric whose broker configuration the API server defaulted", func() {
+		fabric := &Fabric{
+			Spec: FabricSpec{
+				BrokerConfig: BrokerConfig{
+					ServiceDiscoveryEnabled:      true,
+					GlobalnetCIDRRange:           "242.0.0.0/8",
+					DefaultGlobalnetClusterSize:  65336,
+					GlobalCIDRReleaseGracePeriod: &metav1.Duration{Duration: time.Hour},
+				},
+				JoinConfig: JoinConfig{ClusterID: "cluster1"},
+			},
+		}
+		hub := &v1alpha2.Fabric{}
+		Expect(fabric.ConvertTo(hub)).To(Succeed())
+		Expect(hub.Spec.BrokerConfig).To(BeNil())
+		deployBroker, joinBroker := hub.Spec.RequestedRoles()
+		Expect(deployBroker).To(BeFalse())
+		Expect(joinBroker).To(BeTrue())
+
+		fabric.Spec.Roles = []FabricRole{FabricRoleBroker, FabricRoleJoin}
+		hub = &v1alpha2.Fabric{}
+		Expect(fabric.ConvertTo(hub)).To(Succeed())
+		Expect(hub.Spec.BrokerConfig).NotTo(BeNil())
+		deployBroker, joinBroker = hub.Spec.RequestedRoles()
+		Expect(deployBroker).To(BeTrue())
+		Expect(joinBroker).To(BeTrue())
+	})
+})
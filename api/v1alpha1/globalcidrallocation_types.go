@@ -0,0 +1,104 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GlobalCIDRAllocationSpec defines the global CIDRs the broker allocated to a cluster
+type GlobalCIDRAllocationSpec struct {
+	// ClusterID represents the ID of the cluster the global CIDRs are allocated to.
+	ClusterID string `json:"clusterID"`
+	// GlobalCIDRs represents the global CIDRs allocated to the cluster.
+	// +optional
+	GlobalCIDRs []string `json:"globalCIDRs,omitempty"`
+	// Owner represents the fabric which requested the allocation, unset for the allocations migrated from the
+	// globalnet configmap.
+	// +optional
+	Owner *AllocationOwner `json:"owner,omitempty"`
+}
+
+// AllocationOwner identifies the fabric which requested a global CIDR allocation, on the cluster it joined. Each
+// managed cluster of a fabric in hub mode owns its allocation.
+type AllocationOwner struct {
+	// Name represents the name of the fabric.
+	Name string `json:"name"`
+	// Namespace represents the namespace of the fabric.
+	Namespace string `json:"namespace"`
+	// ClusterID represents the ID of the managed cluster the fabric joined in hub mode, unset when the fabric
+	// joined its own cluster.
+	// +optional
+	ClusterID string `json:"clusterID,omitempty"`
+}
+
+// GlobalCIDRAllocationStatus defines the observed state of GlobalCIDRAllocation
+type GlobalCIDRAllocationStatus struct {
+	// Phase represents the phase of the allocation.
+	// +optional
+	Phase AllocationPhase `json:"phase,omitempty"`
+	// AllocatedTime represents when the global CIDRs were last allocated.
+	// +optional
+	AllocatedTime *metav1.Time `json:"allocatedTime,omitempty"`
+	// ReleasedTime represents when the cluster released the global CIDRs, they are reclaimed once the release
+	// grace period of the broker is over.
+	// +optional
+	ReleasedTime *metav1.Time `json:"releasedTime,omitempty"`
+	// Migrated represents whether the allocation was migrated from the globalnet configmap.
+	// +optional
+	Migrated bool `json:"migrated,omitempty"`
+}
+
+// AllocationPhase is the phase of a global CIDR allocation.
+type AllocationPhase string
+
+const (
+	// AllocationPhaseAllocated means the global CIDRs are in use by the cluster.
+	AllocationPhaseAllocated AllocationPhase = "Allocated"
+	// AllocationPhaseReleased means the cluster left the broker, the global CIDRs are not reused before the
+	// release grace period is over.
+	AllocationPhaseReleased AllocationPhase = "Released"
+)
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+// +kubebuilder:resource:path=globalcidrallocations,shortName=gca,scope=Namespaced
+// +kubebuilder:printcolumn:name="Cluster",type=string,JSONPath=.spec.clusterID
+// +kubebuilder:printcolumn:name="Global CIDRs",type=string,JSONPath=.spec.globalCIDRs
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=.status.phase
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=.metadata.creationTimestamp
+// GlobalCIDRAllocation is the Schema for the globalcidrallocations API, one per cluster joined to a globalnet broker
+type GlobalCIDRAllocation struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   GlobalCIDRAllocationSpec   `json:"spec,omitempty"`
+	Status GlobalCIDRAllocationStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// GlobalCIDRAllocationList contains a list of GlobalCIDRAllocation
+type GlobalCIDRAllocationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []GlobalCIDRAllocation `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&GlobalCIDRAllocation{}, &GlobalCIDRAllocationList{})
+}
@@ -22,6 +22,7 @@ package v1alpha1
 
 import (
 	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -40,6 +41,21 @@ func (in *AWS) DeepCopy() *AWS {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AllocationOwner) DeepCopyInto(out *AllocationOwner) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AllocationOwner.
+func (in *AllocationOwner) DeepCopy() *AllocationOwner {
+	if in == nil {
+		return nil
+	}
+	out := new(AllocationOwner)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BrokerConfig) DeepCopyInto(out *BrokerConfig) {
 	*out = *in
@@ -48,6 +64,11 @@ func (in *BrokerConfig) DeepCopyInto(out *BrokerConfig) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.GlobalCIDRReleaseGracePeriod != nil {
+		in, out := &in.GlobalCIDRReleaseGracePeriod, &out.GlobalCIDRReleaseGracePeriod
+		*out = new(metav1.Duration)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BrokerConfig.
@@ -87,7 +108,7 @@ func (in *Fabric) DeepCopyInto(out *Fabric) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Fabric.
@@ -146,6 +167,18 @@ func (in *FabricSpec) DeepCopyInto(out *FabricSpec) {
 	in.BrokerConfig.DeepCopyInto(&out.BrokerConfig)
 	in.JoinConfig.DeepCopyInto(&out.JoinConfig)
 	in.CloudPrepareConfig.DeepCopyInto(&out.CloudPrepareConfig)
+	if in.ManagedClusters != nil {
+		in, out := &in.ManagedClusters, &out.ManagedClusters
+		*out = make([]ManagedCluster, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Roles != nil {
+		in, out := &in.Roles, &out.Roles
+		*out = make([]FabricRole, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricSpec.
@@ -161,6 +194,37 @@ func (in *FabricSpec) DeepCopy() *FabricSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FabricStatus) DeepCopyInto(out *FabricStatus) {
 	*out = *in
+	if in.Network != nil {
+		in, out := &in.Network, &out.Network
+		*out = new(NetworkStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ManagedClusters != nil {
+		in, out := &in.ManagedClusters, &out.ManagedClusters
+		*out = make([]ManagedClusterStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.JoinedClusters != nil {
+		in, out := &in.JoinedClusters, &out.JoinedClusters
+		*out = make([]JoinedClusterStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.GlobalnetCapacity != nil {
+		in, out := &in.GlobalnetCapacity, &out.GlobalnetCapacity
+		*out = new(GlobalnetCapacity)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricStatus.
@@ -173,6 +237,143 @@ func (in *FabricStatus) DeepCopy() *FabricStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GatewayStatus) DeepCopyInto(out *GatewayStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GatewayStatus.
+func (in *GatewayStatus) DeepCopy() *GatewayStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(GatewayStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GlobalCIDRAllocation) DeepCopyInto(out *GlobalCIDRAllocation) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GlobalCIDRAllocation.
+func (in *GlobalCIDRAllocation) DeepCopy() *GlobalCIDRAllocation {
+	if in == nil {
+		return nil
+	}
+	out := new(GlobalCIDRAllocation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GlobalCIDRAllocation) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GlobalCIDRAllocationList) DeepCopyInto(out *GlobalCIDRAllocationList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]GlobalCIDRAllocation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GlobalCIDRAllocationList.
+func (in *GlobalCIDRAllocationList) DeepCopy() *GlobalCIDRAllocationList {
+	if in == nil {
+		return nil
+	}
+	out := new(GlobalCIDRAllocationList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GlobalCIDRAllocationList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GlobalCIDRAllocationSpec) DeepCopyInto(out *GlobalCIDRAllocationSpec) {
+	*out = *in
+	if in.GlobalCIDRs != nil {
+		in, out := &in.GlobalCIDRs, &out.GlobalCIDRs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Owner != nil {
+		in, out := &in.Owner, &out.Owner
+		*out = new(AllocationOwner)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GlobalCIDRAllocationSpec.
+func (in *GlobalCIDRAllocationSpec) DeepCopy() *GlobalCIDRAllocationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(GlobalCIDRAllocationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GlobalCIDRAllocationStatus) DeepCopyInto(out *GlobalCIDRAllocationStatus) {
+	*out = *in
+	if in.AllocatedTime != nil {
+		in, out := &in.AllocatedTime, &out.AllocatedTime
+		*out = (*in).DeepCopy()
+	}
+	if in.ReleasedTime != nil {
+		in, out := &in.ReleasedTime, &out.ReleasedTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GlobalCIDRAllocationStatus.
+func (in *GlobalCIDRAllocationStatus) DeepCopy() *GlobalCIDRAllocationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(GlobalCIDRAllocationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GlobalnetCapacity) DeepCopyInto(out *GlobalnetCapacity) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GlobalnetCapacity.
+func (in *GlobalnetCapacity) DeepCopy() *GlobalnetCapacity {
+	if in == nil {
+		return nil
+	}
+	out := new(GlobalnetCapacity)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *JoinConfig) DeepCopyInto(out *JoinConfig) {
 	*out = *in
@@ -186,6 +387,16 @@ func (in *JoinConfig) DeepCopyInto(out *JoinConfig) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.BrokerInfoSecretRef != nil {
+		in, out := &in.BrokerInfoSecretRef, &out.BrokerInfoSecretRef
+		*out = new(v1.SecretReference)
+		**out = **in
+	}
+	if in.BrokerKubeConfigSecretRef != nil {
+		in, out := &in.BrokerKubeConfigSecretRef, &out.BrokerKubeConfigSecretRef
+		*out = new(v1.SecretReference)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JoinConfig.
@@ -197,3 +408,106 @@ func (in *JoinConfig) DeepCopy() *JoinConfig {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JoinedClusterStatus) DeepCopyInto(out *JoinedClusterStatus) {
+	*out = *in
+	if in.GlobalCIDRs != nil {
+		in, out := &in.GlobalCIDRs, &out.GlobalCIDRs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Gateways != nil {
+		in, out := &in.Gateways, &out.Gateways
+		*out = make([]GatewayStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastSeen != nil {
+		in, out := &in.LastSeen, &out.LastSeen
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JoinedClusterStatus.
+func (in *JoinedClusterStatus) DeepCopy() *JoinedClusterStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(JoinedClusterStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManagedCluster) DeepCopyInto(out *ManagedCluster) {
+	*out = *in
+	out.KubeConfigSecretRef = in.KubeConfigSecretRef
+	in.JoinConfig.DeepCopyInto(&out.JoinConfig)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagedCluster.
+func (in *ManagedCluster) DeepCopy() *ManagedCluster {
+	if in == nil {
+		return nil
+	}
+	out := new(ManagedCluster)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManagedClusterStatus) DeepCopyInto(out *ManagedClusterStatus) {
+	*out = *in
+	if in.Network != nil {
+		in, out := &in.Network, &out.Network
+		*out = new(NetworkStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagedClusterStatus.
+func (in *ManagedClusterStatus) DeepCopy() *ManagedClusterStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ManagedClusterStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkStatus) DeepCopyInto(out *NetworkStatus) {
+	*out = *in
+	if in.DiscoveredPodCIDRs != nil {
+		in, out := &in.DiscoveredPodCIDRs, &out.DiscoveredPodCIDRs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DiscoveredServiceCIDRs != nil {
+		in, out := &in.DiscoveredServiceCIDRs, &out.DiscoveredServiceCIDRs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.GlobalCIDRs != nil {
+		in, out := &in.GlobalCIDRs, &out.GlobalCIDRs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkStatus.
+func (in *NetworkStatus) DeepCopy() *NetworkStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkStatus)
+	in.DeepCopyInto(out)
+	return out
+}
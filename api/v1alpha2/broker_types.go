@@ -0,0 +1,100 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// BrokerSpec defines the desired state of Broker
+type BrokerSpec struct {
+	// BrokerConfig represents the broker cluster configuration of the Submariner.
+	// +optional
+	BrokerConfig `json:"brokerConfig,omitempty"`
+
+	// ManagedClusters represents the remote clusters joined to the broker by the operator deploying the broker,
+	// so a single control plane manages the whole fleet (hub mode).
+	// +optional
+	ManagedClusters []ManagedCluster `json:"managedClusters,omitempty"`
+}
+
+// BrokerStatus defines the observed state of Broker
+type BrokerStatus struct {
+	// Phase is the phase of the broker deployment.
+	// +optional
+	Phase Phase `json:"phase,omitempty"`
+
+	// Message is the reason of the last failure, empty once the broker is running.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// ObservedGeneration is the generation of the spec the status was computed from.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions represent the latest available observations of the broker deployment stages.
+	// +optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+
+	// ManagedClusters represents the join status of the managed clusters of the broker.
+	// +optional
+	// +listType=map
+	// +listMapKey=clusterID
+	ManagedClusters []ManagedClusterStatus `json:"managedClusters,omitempty"`
+
+	// JoinedClusters represents the clusters joined to the broker.
+	// +optional
+	// +listType=map
+	// +listMapKey=clusterID
+	JoinedClusters []JoinedClusterStatus `json:"joinedClusters,omitempty"`
+
+	// GlobalnetCapacity represents the allocation of the globalnet supernet, when globalnet is enabled.
+	// +optional
+	GlobalnetCapacity *GlobalnetCapacity `json:"globalnetCapacity,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+// +kubebuilder:resource:path=brokers,scope=Namespaced
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=.metadata.creationTimestamp
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=.status.phase,description="Current Broker Phase"
+// +kubebuilder:printcolumn:name="Message",type=string,JSONPath=.status.message,description="Reason of the last failure"
+// Broker is the Schema for the brokers API, the cluster it lives in hosts the Submariner broker
+type Broker struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   BrokerSpec   `json:"spec,omitempty"`
+	Status BrokerStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// BrokerList contains a list of Broker
+type BrokerList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Broker `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Broker{}, &BrokerList{})
+}
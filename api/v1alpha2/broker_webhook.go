@@ -0,0 +1,64 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// SetupWebhookWithManager registers the broker webhooks with the webhook server of the manager
+func (r *Broker) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/validate-operator-tkestack-io-v1alpha2-broker,mutating=false,failurePolicy=fail,sideEffects=None,groups=operator.tkestack.io,resources=brokers,verbs=create;update,versions=v1alpha2,name=vbroker.kb.io,admissionReviewVersions={v1,v1beta1}
+
+var _ webhook.Validator = &Broker{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *Broker) ValidateCreate() error {
+	klog.V(4).Infof("Validating the creation of broker %s/%s", r.GetNamespace(), r.GetName())
+	return r.validateBroker()
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *Broker) ValidateUpdate(old runtime.Object) error {
+	klog.V(4).Infof("Validating the update of broker %s/%s", r.GetNamespace(), r.GetName())
+	return r.validateBroker()
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *Broker) ValidateDelete() error {
+	return nil
+}
+
+func (r *Broker) validateBroker() error {
+	specPath := field.NewPath("spec")
+	allErrs := validateBrokerConfig(&r.Spec.BrokerConfig, specPath.Child("brokerConfig"))
+	allErrs = append(allErrs, validateManagedClusters(r.Spec.ManagedClusters, specPath.Child("managedClusters"))...)
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(GroupVersion.WithKind("Broker").GroupKind(), r.GetName(), allErrs)
+}
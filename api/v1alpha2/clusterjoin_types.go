@@ -0,0 +1,87 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ClusterJoinSpec defines the desired state of ClusterJoin
+type ClusterJoinSpec struct {
+	// JoinConfig represents the managed cluster join configuration of the Submariner.
+	JoinConfig `json:"joinConfig"`
+
+	// CloudPrepareConfig represents the prepare config for the cloud vendor.
+	// +optional
+	CloudPrepareConfig `json:"cloudPrepareConfig,omitempty"`
+}
+
+// ClusterJoinStatus defines the observed state of ClusterJoin
+type ClusterJoinStatus struct {
+	// Phase is the phase of the join.
+	// +optional
+	Phase Phase `json:"phase,omitempty"`
+
+	// Message is the reason of the last failure, empty once the cluster is joined.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// ObservedGeneration is the generation of the spec the status was computed from.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Network represents the network details of the joined cluster.
+	// +optional
+	Network *NetworkStatus `json:"network,omitempty"`
+
+	// Conditions represent the latest available observations of the join stages.
+	// +optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+// +kubebuilder:resource:path=clusterjoins,shortName=cj,scope=Namespaced
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=.metadata.creationTimestamp
+// +kubebuilder:printcolumn:name="Cluster ID",type=string,JSONPath=.spec.joinConfig.clusterID
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=.status.phase,description="Current Join Phase"
+// +kubebuilder:printcolumn:name="Message",type=string,JSONPath=.status.message,description="Reason of the last failure"
+// ClusterJoin is the Schema for the clusterjoins API, the cluster it lives in is joined to a Submariner broker
+type ClusterJoin struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ClusterJoinSpec   `json:"spec,omitempty"`
+	Status ClusterJoinStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ClusterJoinList contains a list of ClusterJoin
+type ClusterJoinList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterJoin `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterJoin{}, &ClusterJoinList{})
+}
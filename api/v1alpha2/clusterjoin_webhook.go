@@ -0,0 +1,69 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// SetupWebhookWithManager registers the cluster join webhooks with the webhook server of the manager
+func (r *ClusterJoin) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/validate-operator-tkestack-io-v1alpha2-clusterjoin,mutating=false,failurePolicy=fail,sideEffects=None,groups=operator.tkestack.io,resources=clusterjoins,verbs=create;update,versions=v1alpha2,name=vclusterjoin.kb.io,admissionReviewVersions={v1,v1beta1}
+
+var _ webhook.Validator = &ClusterJoin{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *ClusterJoin) ValidateCreate() error {
+	klog.V(4).Infof("Validating the creation of cluster join %s/%s", r.GetNamespace(), r.GetName())
+	return r.validateClusterJoin()
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *ClusterJoin) ValidateUpdate(old runtime.Object) error {
+	klog.V(4).Infof("Validating the update of cluster join %s/%s", r.GetNamespace(), r.GetName())
+	return r.validateClusterJoin()
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *ClusterJoin) ValidateDelete() error {
+	return nil
+}
+
+// validateClusterJoin requires the cluster ID, unlike the fabrics a cluster join always joins its cluster
+func (r *ClusterJoin) validateClusterJoin() error {
+	joinConfigPath := field.NewPath("spec").Child("joinConfig")
+	var allErrs field.ErrorList
+	if r.Spec.JoinConfig.ClusterID == "" {
+		allErrs = append(allErrs, field.Required(joinConfigPath.Child("clusterID"), "the joined cluster needs a cluster ID"))
+	} else {
+		allErrs = append(allErrs, validateJoinConfig(&r.Spec.JoinConfig, joinConfigPath)...)
+	}
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(GroupVersion.WithKind("ClusterJoin").GroupKind(), r.GetName(), allErrs)
+}
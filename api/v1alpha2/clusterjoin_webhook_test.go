@@ -0,0 +1,37 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+var _ = Describe("ClusterJoin validation", func() {
+	It("Should accept a valid cluster join", func() {
+		clusterJoin := &ClusterJoin{Spec: ClusterJoinSpec{JoinConfig: JoinConfig{ClusterID: "cluster1", IkePort: 500}}}
+		Expect(clusterJoin.ValidateCreate()).To(Succeed())
+	})
+
+	It("Should require the cluster ID", func() {
+		clusterJoin := &ClusterJoin{}
+		err := clusterJoin.ValidateCreate()
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.joinConfig.clusterID"))
+	})
+})
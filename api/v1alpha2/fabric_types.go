@@ -0,0 +1,522 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
+// NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
+
+// FabricSpec defines the desired state of Fabric
+type FabricSpec struct {
+	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
+	// Important: Run "make" to regenerate code after modifying this file
+
+	// BrokerConfig represents the broker cluster configuration of the Submariner, the fabric deploys the broker
+	// when it is set and no roles are stated.
+	// +optional
+	BrokerConfig *BrokerConfig `json:"brokerConfig,omitempty"`
+
+	// JoinConfig represents the managed cluster join configuration of the Submariner.
+	// +optional
+	JoinConfig `json:"joinConfig,omitempty"`
+
+	// CloudPrepareConfig represents the prepare config for the cloud vendor.
+	// +optional
+	CloudPrepareConfig `json:"cloudPrepareConfig,omitempty"`
+
+	// ManagedClusters represents the remote clusters joined to the broker by the operator deploying the broker,
+	// so a single control plane manages the whole fleet (hub mode).
+	// +optional
+	ManagedClusters []ManagedCluster `json:"managedClusters,omitempty"`
+
+	// Roles represents the roles the fabric requests, Broker to deploy the broker and Join to join the cluster to
+	// the broker. When unset, the fabric deploys the broker when brokerConfig or managedClusters are set, and joins
+	// the cluster when joinConfig.clusterID is set.
+	// +optional
+	Roles []FabricRole `json:"roles,omitempty"`
+}
+
+// FabricRole is a role the operator performs for a fabric.
+// +kubebuilder:validation:Enum=Broker;Join
+type FabricRole string
+
+const (
+	// FabricRoleBroker deploys the broker on the cluster of the fabric.
+	FabricRoleBroker FabricRole = "Broker"
+	// FabricRoleJoin joins the cluster of the fabric to the broker.
+	FabricRoleJoin FabricRole = "Join"
+)
+
+// ManagedCluster represents a remote cluster joined to the broker in hub mode.
+type ManagedCluster struct {
+	// KubeConfigSecretRef is a reference to a secret holding a kubeconfig of the managed cluster, under the
+	// "kubeconfig" key. The namespace defaults to the namespace of the fabric.
+	KubeConfigSecretRef corev1.SecretReference `json:"kubeConfigSecretRef"`
+	// JoinConfig represents the join configuration of the managed cluster.
+	JoinConfig JoinConfig `json:"joinConfig"`
+}
+
+// FabricStatus defines the observed state of Fabric
+type FabricStatus struct {
+	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
+	// Important: Run "make" to regenerate code after modifying this file
+
+	// Phase is the fabric operator running phase.
+	// +optional
+	Phase Phase `json:"phase,omitempty"`
+
+	// Message is a human readable message indicating why the last reconciliation failed.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// ObservedGeneration is the most recent generation observed by the fabric operator.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Network represents the network details the cluster joined the broker with.
+	// +optional
+	Network *NetworkStatus `json:"network,omitempty"`
+
+	// Conditions represents the outcome of each reconciliation stage.
+	// +optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+
+	// ManagedClusters represents the join status of each managed cluster, in hub mode.
+	// +optional
+	// +listType=map
+	// +listMapKey=clusterID
+	ManagedClusters []ManagedClusterStatus `json:"managedClusters,omitempty"`
+
+	// JoinedClusters represents the clusters joined to the broker, as seen from the broker.
+	// +optional
+	// +listType=map
+	// +listMapKey=clusterID
+	JoinedClusters []JoinedClusterStatus `json:"joinedClusters,omitempty"`
+
+	// GlobalnetCapacity represents how much of the globalnet supernet of the broker is allocated.
+	// +optional
+	GlobalnetCapacity *GlobalnetCapacity `json:"globalnetCapacity,omitempty"`
+}
+
+// GlobalnetCapacity represents how much of the globalnet supernet is allocated to the joined clusters. The IP
+// counts of IPv6 supernets are capped to the largest int64.
+type GlobalnetCapacity struct {
+	// CIDRRange represents the globalnet supernet.
+	CIDRRange string `json:"cidrRange"`
+
+	// TotalIPs represents the amount of IPs of the supernet.
+	TotalIPs int64 `json:"totalIPs"`
+
+	// AllocatedBlocks represents the amount of global CIDRs allocated from the supernet.
+	AllocatedBlocks int32 `json:"allocatedBlocks"`
+
+	// AllocatedIPs represents the amount of IPs of the supernet allocated to the clusters.
+	AllocatedIPs int64 `json:"allocatedIPs"`
+
+	// FreeIPs represents the amount of IPs of the supernet not allocated yet.
+	FreeIPs int64 `json:"freeIPs"`
+
+	// LargestFreeBlockIPs represents the amount of IPs of the largest contiguous free range of the supernet.
+	LargestFreeBlockIPs int64 `json:"largestFreeBlockIPs"`
+
+	// FragmentationPercent represents the share of the free IPs outside the largest free range.
+	FragmentationPercent int32 `json:"fragmentationPercent"`
+}
+
+// JoinedClusterStatus represents a cluster joined to the broker.
+type JoinedClusterStatus struct {
+	// ClusterID represents the cluster ID of the joined cluster.
+	ClusterID string `json:"clusterID"`
+
+	// GlobalCIDRs represents the global CIDRs allocated to the cluster.
+	// +optional
+	GlobalCIDRs []string `json:"globalCIDRs,omitempty"`
+
+	// Gateways represents the gateway endpoints the cluster published to the broker.
+	// +optional
+	Gateways []GatewayStatus `json:"gateways,omitempty"`
+
+	// LastSeen represents the last time the cluster updated its objects on the broker.
+	// +optional
+	LastSeen *metav1.Time `json:"lastSeen,omitempty"`
+}
+
+// GatewayStatus represents a gateway endpoint published to the broker.
+type GatewayStatus struct {
+	// Hostname represents the hostname of the gateway node.
+	// +optional
+	Hostname string `json:"hostname,omitempty"`
+	// CableName represents the name of the cable the gateway connects with.
+	// +optional
+	CableName string `json:"cableName,omitempty"`
+	// Backend represents the cable driver of the gateway.
+	// +optional
+	Backend string `json:"backend,omitempty"`
+	// PrivateIP represents the private IP of the gateway.
+	// +optional
+	PrivateIP string `json:"privateIP,omitempty"`
+	// PublicIP represents the public IP of the gateway.
+	// +optional
+	PublicIP string `json:"publicIP,omitempty"`
+	// NATEnabled represents whether the gateway is behind NAT.
+	// +optional
+	NATEnabled bool `json:"natEnabled,omitempty"`
+}
+
+// ManagedClusterStatus represents the join status of a cluster managed in hub mode.
+type ManagedClusterStatus struct {
+	// ClusterID represents the cluster ID of the managed cluster.
+	ClusterID string `json:"clusterID"`
+
+	// Phase is the join phase of the managed cluster.
+	// +optional
+	Phase Phase `json:"phase,omitempty"`
+
+	// Message is a human readable message indicating why the last join failed.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// Network represents the network details the managed cluster joined the broker with.
+	// +optional
+	Network *NetworkStatus `json:"network,omitempty"`
+
+	// Conditions represents the outcome of each join stage.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// NetworkStatus represents the discovered and the effective network details of the managed cluster.
+type NetworkStatus struct {
+	// NetworkPlugin represents the discovered network plugin.
+	// +optional
+	NetworkPlugin string `json:"networkPlugin,omitempty"`
+	// DiscoveredPodCIDRs represents the pod CIDRs found by network discovery.
+	// +optional
+	DiscoveredPodCIDRs []string `json:"discoveredPodCIDRs,omitempty"`
+	// DiscoveredServiceCIDRs represents the service CIDRs found by network discovery.
+	// +optional
+	DiscoveredServiceCIDRs []string `json:"discoveredServiceCIDRs,omitempty"`
+	// ClusterCIDR represents the pod CIDR used to join the cluster.
+	// +optional
+	ClusterCIDR string `json:"clusterCIDR,omitempty"`
+	// ClusterCIDRAutoDetected represents whether the pod CIDR was auto-detected or supplied by the user.
+	// +optional
+	ClusterCIDRAutoDetected bool `json:"clusterCIDRAutoDetected,omitempty"`
+	// ServiceCIDR represents the service CIDR used to join the cluster.
+	// +optional
+	ServiceCIDR string `json:"serviceCIDR,omitempty"`
+	// ServiceCIDRAutoDetected represents whether the service CIDR was auto-detected or supplied by the user.
+	// +optional
+	ServiceCIDRAutoDetected bool `json:"serviceCIDRAutoDetected,omitempty"`
+	// GlobalCIDR represents the first global CIDR allocated to the cluster.
+	// +optional
+	GlobalCIDR string `json:"globalCIDR,omitempty"`
+	// GlobalCIDRs represents all the global CIDRs allocated to the cluster, additional ones are allocated when
+	// the cluster size is raised.
+	// +optional
+	GlobalCIDRs []string `json:"globalCIDRs,omitempty"`
+	// LoadBalancerAddress represents the external address of the LoadBalancer in front of the gateways.
+	// +optional
+	LoadBalancerAddress string `json:"loadBalancerAddress,omitempty"`
+}
+
+const (
+	PhaseRunning Phase = "Running"
+	PhaseFailed  Phase = "Failed"
+)
+
+// Phase is the phase of the installation.
+type Phase string
+
+// IsEnabled reports whether a feature enabled by default is enabled, the feature is enabled when its switch is
+// left unset.
+func IsEnabled(enabled *bool) bool {
+	return enabled == nil || *enabled
+}
+
+// GetBrokerConfig returns the broker configuration of the fabric, the default one when the fabric has none.
+func (spec *FabricSpec) GetBrokerConfig() *BrokerConfig {
+	if spec.BrokerConfig == nil {
+		return &BrokerConfig{}
+	}
+	return spec.BrokerConfig
+}
+
+// RequestedRoles returns whether the fabric requests to deploy the broker and to join its cluster to the broker,
+// from its roles or, when it states none, from the sections of its spec.
+func (spec *FabricSpec) RequestedRoles() (deployBroker, joinBroker bool) {
+	if len(spec.Roles) == 0 {
+		return spec.BrokerConfig != nil || len(spec.ManagedClusters) > 0, spec.JoinConfig.ClusterID != ""
+	}
+	for _, role := range spec.Roles {
+		switch role {
+		case FabricRoleBroker:
+			deployBroker = true
+		case FabricRoleJoin:
+			joinBroker = true
+		}
+	}
+	return deployBroker, joinBroker
+}
+
+// Condition types reported in the fabric status, one per reconciliation stage.
+const (
+	// ConditionBrokerReady reports whether the broker is deployed, or reachable from the managed cluster.
+	ConditionBrokerReady = "BrokerReady"
+	// ConditionRequirementsMet reports whether the join configuration and the target cluster meet Submariner's requirements.
+	ConditionRequirementsMet = "RequirementsMet"
+	// ConditionNetworkDiscovered reports whether the pod and service CIDRs of the cluster could be determined.
+	ConditionNetworkDiscovered = "NetworkDiscovered"
+	// ConditionGlobalnetAllocated reports whether a global CIDR is allocated to the cluster.
+	ConditionGlobalnetAllocated = "GlobalnetAllocated"
+	// ConditionCIDRsDisjoint reports whether the pod and service CIDRs of the cluster overlap none of the clusters
+	// joined to the broker, when the broker has globalnet disabled.
+	ConditionCIDRsDisjoint = "CIDRsDisjoint"
+	// ConditionOperatorDeployed reports whether the submariner operator is deployed.
+	ConditionOperatorDeployed = "OperatorDeployed"
+	// ConditionSubmarinerDeployed reports whether the Submariner CR is deployed.
+	ConditionSubmarinerDeployed = "SubmarinerDeployed"
+	// ConditionServiceDiscoveryDeployed reports whether the ServiceDiscovery CR is deployed.
+	ConditionServiceDiscoveryDeployed = "ServiceDiscoveryDeployed"
+	// ConditionIPSecPSKCurrent reports whether the broker info copy read through brokerInfoSecretRef holds the
+	// IPsec PSK currently persisted on the broker.
+	ConditionIPSecPSKCurrent = "IPSecPSKCurrent"
+	// ConditionRolePermitted reports whether the operator is permitted to deploy the broker and to join the cluster,
+	// as requested by the fabric.
+	ConditionRolePermitted = "RolePermitted"
+)
+
+// Condition reasons reported in the fabric status.
+const (
+	ReasonSucceeded     = "Succeeded"
+	ReasonFailed        = "Failed"
+	ReasonInvalidConfig = "InvalidConfig"
+	// ReasonOverlappingCIDRs means the CIDRs of the cluster overlap the ones of a joined cluster.
+	ReasonOverlappingCIDRs = "OverlappingCIDRs"
+	// ReasonStaleIPSecPSK means the broker info copy holds an IPsec PSK the broker has rotated since.
+	ReasonStaleIPSecPSK = "StaleIPSecPSK"
+	// ReasonRoleNotPermitted means the fabric requests a role the operator is not permitted to perform.
+	ReasonRoleNotPermitted = "RoleNotPermitted"
+)
+
+type BrokerConfig struct {
+	// ServiceDiscoveryEnabled represents enable/disable multi-cluster service discovery.
+	// The broker publishes it in the broker info, the joining clusters deploy service discovery accordingly.
+	// +optional
+	// +kubebuilder:default=true
+	ServiceDiscoveryEnabled *bool `json:"serviceDiscoveryEnabled,omitempty"`
+	// GlobalnetEnable represents enable/disable overlapping CIDRs in connecting clusters (default disabled).
+	// The broker publishes it in the broker info, the joined clusters deploy globalnet accordingly on their next resync.
+	// +optional
+	// +kubebuilder:default=false
+	GlobalnetEnable bool `json:"globalnetEnable,omitempty"`
+	// GlobalnetCIDRRange represents global CIDR supernet range for allocating global CIDRs to each cluster,
+	// either IPv4 or IPv6.
+	// +optional
+	// +kubebuilder:default="242.0.0.0/8"
+	GlobalnetCIDRRange string `json:"globalnetCIDRRange,omitempty"`
+	// DefaultGlobalnetClusterSize represents default cluster size for global CIDR allocated to each cluster (amount of global IPs).
+	// +optional
+	// +kubebuilder:default=65336
+	DefaultGlobalnetClusterSize uint `json:"defaultGlobalnetClusterSize,omitempty"`
+	// DefaultCustomDomains represents list of domains to use for multicluster service discovery.
+	// +optional
+	DefaultCustomDomains []string `json:"defaultCustomDomains,omitempty"`
+	// GlobalCIDRReleaseGracePeriod represents how long the global CIDRs of a cluster which left the broker are kept
+	// before they can be allocated to another cluster.
+	// +optional
+	// +kubebuilder:default="1h"
+	GlobalCIDRReleaseGracePeriod *metav1.Duration `json:"globalCIDRReleaseGracePeriod,omitempty"`
+	// IPSecPSKRotation represents the rotation trigger of the IPsec PSK shared by the joined clusters. The PSK is
+	// generated once and kept across reconciles, setting this to a new value generates a new PSK. The clusters
+	// joined by the operator deploying the broker are rejoined with the new PSK right away, the clusters joined by
+	// the operators of other clusters pick it up on their next broker info resync, up to 5 minutes later. The
+	// tunnels between clusters holding different PSKs are down during that window. The clusters reading a copy of
+	// the broker info through brokerInfoSecretRef keep the former PSK until the copy is refreshed, their
+	// IPSecPSKCurrent condition turns false meanwhile.
+	// +optional
+	IPSecPSKRotation string `json:"ipsecPSKRotation,omitempty"`
+}
+
+type JoinConfig struct {
+	// ClusterID used to identify the tunnels.
+	ClusterID string `json:"clusterID"`
+	// ServiceCIDR represents service CIDR.
+	// +optional
+	ServiceCIDR string `json:"serviceCIDR,omitempty"`
+	// ClusterCIDR represents cluster CIDR.
+	// +optional
+	ClusterCIDR string `json:"clusterCIDR,omitempty"`
+	// GlobalCIDR represents global CIDR to be allocated to the cluster.
+	// +optional
+	GlobalnetCIDR string `json:"globalnetCIDR,omitempty"`
+	// Repository represents image repository.
+	// +optional
+	Repository string `json:"repository,omitempty"`
+	// ImageVersion represents image version.
+	// +optional
+	ImageVersion string `json:"imageVersion,omitempty"`
+	// NattPort represents IPsec NAT-T port (default 4500).
+	// +optional
+	// +kubebuilder:default=4500
+	NattPort int `json:"nattPort,omitempty"`
+	// IkePort represents IPsec IKE port (default 500).
+	// +optional
+	// +kubebuilder:default=500
+	IkePort int `json:"ikePort,omitempty"`
+	// PreferredServer represents enable/disable this cluster as a preferred server for data-plane connections.
+	// +optional
+	// +kubebuilder:default=false
+	PreferredServer bool `json:"preferredServer,omitempty"`
+	// ForceUDPEncaps represents force UDP encapsulation for IPSec.
+	// +optional
+	// +kubebuilder:default=false
+	ForceUDPEncaps bool `json:"forceUDPEncaps,omitempty"`
+	// NatTraversal represents enable NAT traversal for IPsec
+	// +optional
+	// +kubebuilder:default=true
+	NatTraversal *bool `json:"natTraversal,omitempty"`
+	// GlobalnetEnabled represents enable/disable Globalnet for this cluster.
+	// +optional
+	// +kubebuilder:default=true
+	GlobalnetEnabled *bool `json:"globalnetEnabled,omitempty"`
+	// IpsecDebug represents enable/disable IPsec debugging (verbose logging).
+	// +optional
+	// +kubebuilder:default=false
+	IpsecDebug bool `json:"ipsecDebug,omitempty"`
+	// SubmarinerDebug represents enable/disable submariner pod debugging (verbose logging in the deployed pods).
+	// +optional
+	// +kubebuilder:default=false
+	SubmarinerDebug bool `json:"submarinerDebug,omitempty"`
+	// LabelGateway represents enable/disable label gateways.
+	// +optional
+	// +kubebuilder:default=true
+	LabelGateway *bool `json:"labelGateway,omitempty"`
+	// LoadBalancerEnabled represents enable/disable automatic LoadBalancer in front of the gateways.
+	// +optional
+	// +kubebuilder:default=false
+	LoadBalancerEnabled bool `json:"loadBalancerEnabled,omitempty"`
+	// CableDriver represents cable driver implementation.
+	// +optional
+	CableDriver string `json:"cableDriver,omitempty"`
+	// GlobalnetClusterSize represents cluster size for GlobalCIDR allocated to this cluster (amount of global IPs).
+	// +optional
+	// +kubebuilder:default=0
+	GlobalnetClusterSize uint `json:"globalnetClusterSize,omitempty"`
+	// CustomDomains represents list of domains to use for multicluster service discovery.
+	// +optional
+	CustomDomains []string `json:"customDomains,omitempty"`
+	// ImageOverrideArr represents override component image.
+	// +optional
+	ImageOverrideArr []string `json:"imageOverrideArr,omitempty"`
+	// HealthCheckEnable represents enable/disable gateway health check.
+	// +optional
+	// +kubebuilder:default=true
+	HealthCheckEnable *bool `json:"healthCheckEnable,omitempty"`
+	// HealthCheckInterval represents interval in seconds between health check packets.
+	// +optional
+	// +kubebuilder:default=1
+	HealthCheckInterval uint64 `json:"healthCheckInterval,omitempty"`
+	// HealthCheckMaxPacketLossCount represents maximum number of packets lost before the connection is marked as down.
+	// +optional
+	// +kubebuilder:default=5
+	HealthCheckMaxPacketLossCount uint64 `json:"healthCheckMaxPacketLossCount,omitempty"`
+	// CorednsCustomConfigMap represents name of the custom CoreDNS configmap to configure forwarding to lighthouse. It should be in
+	// <namespace>/<name> format where <namespace> is optional and defaults to kube-system
+	// +optional
+	CorednsCustomConfigMap string `json:"corednsCustomConfigMap,omitempty"`
+	// BrokerInfoSecretRef is a reference to a secret holding the broker info exported from the broker cluster,
+	// under the "brokerInfo" key. The namespace defaults to the namespace of the fabric.
+	// +optional
+	BrokerInfoSecretRef *corev1.SecretReference `json:"brokerInfoSecretRef,omitempty"`
+	// BrokerKubeConfigSecretRef is a reference to a secret holding a kubeconfig of the broker cluster, under the
+	// "kubeconfig" key. The broker info is read from the secret exported in the broker namespace of the broker cluster.
+	// The namespace defaults to the namespace of the fabric.
+	// +optional
+	BrokerKubeConfigSecretRef *corev1.SecretReference `json:"brokerKubeConfigSecretRef,omitempty"`
+}
+
+type CloudPrepareConfig struct {
+	// CredentialsSecret is a reference to the secret with a certain cloud platform
+	// credentials, the supported platform includes AWS, GCP, Azure, ROKS and OSD.
+	// The cluster-fabric-operator will use these credentials to prepare Submariner cluster
+	// environment. If the submariner cluster environment requires cluster-fabric-operator
+	// preparation, this field should be specified.
+	// +optional
+	CredentialsSecret *corev1.LocalObjectReference `json:"credentialsSecret,omitempty"`
+
+	// Infra ID
+	InfraID string `json:"infraID,omitempty"`
+	// Regio
+	Region string `json:"region,omitempty"`
+
+	// AWS specific cloud prepare setup
+	AWS `json:"aws,omitempty"`
+}
+
+type AWS struct {
+	// GatewayInstance represents type of gateways instance machine (default "m5n.large")
+	// +optional
+	// +kubebuilder:default=m5n.large
+	GatewayInstance string `json:"gatewayInstance,omitempty"`
+
+	// Gateways represents the count of worker nodes that will be used to deploy the Submariner gateway
+	// component on the managed cluster.
+	// +optional
+	// +kubebuilder:default=1
+	Gateways int `json:"gateways,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:storageversion
+// +kubebuilder:resource:path=fabrics,shortName=fb,scope=Namespaced
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=.metadata.creationTimestamp
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=.status.phase,description="Current Cluster Phase"
+// +kubebuilder:printcolumn:name="Message",type=string,JSONPath=.status.message,description="Reason of the last failure"
+// +kubebuilder:printcolumn:name="Created At",type=string,JSONPath=.metadata.creationTimestamp
+// Fabric is the Schema for the fabrics API
+type Fabric struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   FabricSpec   `json:"spec,omitempty"`
+	Status FabricStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// FabricList contains a list of Fabric
+type FabricList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Fabric `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Fabric{}, &FabricList{})
+}
@@ -0,0 +1,229 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	"fmt"
+	"math/bits"
+	"net"
+	"regexp"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+var clusterIDRegexp = regexp.MustCompile("^[a-z0-9][a-z0-9.-]*[a-z0-9]$")
+
+// SetupWebhookWithManager registers the fabric webhooks with the webhook server of the manager
+func (r *Fabric) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/validate-operator-tkestack-io-v1alpha2-fabric,mutating=false,failurePolicy=fail,sideEffects=None,groups=operator.tkestack.io,resources=fabrics,verbs=create;update,versions=v1alpha2,name=vfabric.kb.io,admissionReviewVersions={v1,v1beta1}
+
+var _ webhook.Validator = &Fabric{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *Fabric) ValidateCreate() error {
+	klog.V(4).Infof("Validating the creation of fabric %s/%s", r.GetNamespace(), r.GetName())
+	return r.validateFabric()
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *Fabric) ValidateUpdate(old runtime.Object) error {
+	klog.V(4).Infof("Validating the update of fabric %s/%s", r.GetNamespace(), r.GetName())
+	return r.validateFabric()
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *Fabric) ValidateDelete() error {
+	return nil
+}
+
+func (r *Fabric) validateFabric() error {
+	specPath := field.NewPath("spec")
+	allErrs := validateBrokerConfig(r.Spec.GetBrokerConfig(), specPath.Child("brokerConfig"))
+	// The join configuration is left empty on the fabrics which only deploy the broker, a fabric which requests
+	// no role at all is missing its cluster ID
+	deployBroker, joinBroker := r.Spec.RequestedRoles()
+	if joinBroker || !deployBroker {
+		if r.Spec.JoinConfig.ClusterID == "" {
+			allErrs = append(allErrs, field.Required(specPath.Child("joinConfig", "clusterID"),
+				"a fabric which does not deploy the broker joins its cluster, it needs a cluster ID"))
+		} else {
+			allErrs = append(allErrs, validateJoinConfig(&r.Spec.JoinConfig, specPath.Child("joinConfig"))...)
+		}
+	}
+	if len(r.Spec.ManagedClusters) > 0 && !deployBroker {
+		allErrs = append(allErrs, field.Forbidden(specPath.Child("managedClusters"),
+			"the managed clusters are joined by the fabric deploying the broker"))
+	}
+	allErrs = append(allErrs, validateManagedClusters(r.Spec.ManagedClusters, specPath.Child("managedClusters"))...)
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(GroupVersion.WithKind("Fabric").GroupKind(), r.GetName(), allErrs)
+}
+
+func validateManagedClusters(managedClusters []ManagedCluster, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	for i := range managedClusters {
+		joinConfigPath := fldPath.Index(i).Child("joinConfig")
+		joinConfig := &managedClusters[i].JoinConfig
+		if joinConfig.ClusterID == "" {
+			allErrs = append(allErrs, field.Required(joinConfigPath.Child("clusterID"), "a managed cluster needs a cluster ID"))
+			continue
+		}
+		allErrs = append(allErrs, validateJoinConfig(joinConfig, joinConfigPath)...)
+	}
+	return allErrs
+}
+
+func validateBrokerConfig(brokerConfig *BrokerConfig, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if !brokerConfig.GlobalnetEnable {
+		return allErrs
+	}
+	cidrPath := fldPath.Child("globalnetCIDRRange")
+	if _, _, err := net.ParseCIDR(brokerConfig.GlobalnetCIDRRange); err != nil {
+		return append(allErrs, field.Invalid(cidrPath, brokerConfig.GlobalnetCIDRRange, err.Error()))
+	}
+	if err := ValidateClusterSize(brokerConfig.GlobalnetCIDRRange, brokerConfig.DefaultGlobalnetClusterSize); err != nil {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("defaultGlobalnetClusterSize"),
+			brokerConfig.DefaultGlobalnetClusterSize, err.Error()))
+	}
+	return allErrs
+}
+
+func validateJoinConfig(joinConfig *JoinConfig, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if err := ValidateClusterID(joinConfig.ClusterID); err != nil {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("clusterID"), joinConfig.ClusterID, err.Error()))
+	}
+	allErrs = append(allErrs, validateCIDRList(joinConfig.ClusterCIDR, fldPath.Child("clusterCIDR"))...)
+	allErrs = append(allErrs, validateCIDRList(joinConfig.ServiceCIDR, fldPath.Child("serviceCIDR"))...)
+	if joinConfig.GlobalnetCIDR != "" {
+		if _, _, err := net.ParseCIDR(joinConfig.GlobalnetCIDR); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("globalnetCIDR"), joinConfig.GlobalnetCIDR, err.Error()))
+		}
+		if joinConfig.GlobalnetClusterSize != 0 {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("globalnetClusterSize"),
+				"only one of globalnetCIDR and globalnetClusterSize can be set"))
+		}
+	}
+	allErrs = append(allErrs, validatePort(joinConfig.NattPort, fldPath.Child("nattPort"))...)
+	allErrs = append(allErrs, validatePort(joinConfig.IkePort, fldPath.Child("ikePort"))...)
+	for i, override := range joinConfig.ImageOverrideArr {
+		if _, _, err := ParseImageOverride(override); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("imageOverrideArr").Index(i), override, err.Error()))
+		}
+	}
+	if err := ValidateCorednsCustomConfigMap(joinConfig.CorednsCustomConfigMap); err != nil {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("corednsCustomConfigMap"),
+			joinConfig.CorednsCustomConfigMap, err.Error()))
+	}
+	return allErrs
+}
+
+// validateCIDRList validates a comma separated list of CIDRs, as given for dual-stack clusters
+func validateCIDRList(cidrs string, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if cidrs == "" {
+		return allErrs
+	}
+	for _, cidr := range strings.Split(cidrs, ",") {
+		if _, _, err := net.ParseCIDR(strings.TrimSpace(cidr)); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath, cidrs, err.Error()))
+		}
+	}
+	return allErrs
+}
+
+// validatePort accepts an unset port, the default port is used then
+func validatePort(port int, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if port < 0 || port > 65535 {
+		allErrs = append(allErrs, field.Invalid(fldPath, port, "must be between 1 and 65535"))
+	}
+	return allErrs
+}
+
+// ValidateClusterID makes sure the cluster ID is a valid DNS-1123 string
+func ValidateClusterID(clusterID string) error {
+	if !clusterIDRegexp.MatchString(clusterID) {
+		return fmt.Errorf("cluster IDs must be valid DNS-1123 names, with only lowercase alphanumerics,\n"+
+			"'.' or '-' (and the first and last characters must be alphanumerics).\n"+
+			"%s doesn't meet these requirements", clusterID)
+	}
+	return nil
+}
+
+// ValidateClusterSize makes sure a global CIDR of the cluster size, rounded up to a power of 2, fits in half the
+// globalnet supernet
+func ValidateClusterSize(cidrRange string, clusterSize uint) error {
+	_, network, err := net.ParseCIDR(cidrRange)
+	if err != nil {
+		return err
+	}
+	if clusterSize == 0 {
+		return fmt.Errorf("cluster size must be greater than 0")
+	}
+	ones, totalBits := network.Mask.Size()
+	availableBits := totalBits - ones - 1
+	if availableBits < 0 {
+		return fmt.Errorf("globalnet CIDR range %s is too small to allocate global CIDRs from", cidrRange)
+	}
+	if bits.Len(clusterSize-1) > availableBits {
+		return fmt.Errorf("cluster size %d, should be <= %d", clusterSize, uint(1)<<uint(availableBits))
+	}
+	return nil
+}
+
+// ValidImageNames are the component image names the imageOverrideArr entries may override, they match the
+// image names of the Submariner operator
+var ValidImageNames = []string{"submariner-networkplugin-syncer", "submariner-route-agent", "submariner-gateway",
+	"submariner-globalnet", "lighthouse-agent", "lighthouse-coredns", "submariner-operator"}
+
+// ParseImageOverride splits an image override in its component image name and the image to use instead
+func ParseImageOverride(override string) (string, string, error) {
+	parts := strings.SplitN(override, "=", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return "", "", fmt.Errorf("image override %s should be in <name>=<image> format", override)
+	}
+	for _, name := range ValidImageNames {
+		if parts[0] == name {
+			return parts[0], parts[1], nil
+		}
+	}
+	return "", "", fmt.Errorf("invalid image name %s provided. Please choose from %q", parts[0], ValidImageNames)
+}
+
+// ValidateCorednsCustomConfigMap makes sure the custom CoreDNS configmap is in <namespace>/<name> format, the
+// namespace being optional
+func ValidateCorednsCustomConfigMap(corednsCustomConfigMap string) error {
+	if corednsCustomConfigMap != "" && strings.Count(corednsCustomConfigMap, "/") > 1 {
+		return fmt.Errorf("coredns-custom-configmap should be in <namespace>/<name> format, namespace is optional")
+	}
+	return nil
+}
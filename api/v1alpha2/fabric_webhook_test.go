@@ -0,0 +1,129 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+var _ = Describe("Fabric validation", func() {
+	var fabric *Fabric
+
+	BeforeEach(func() {
+		fabric = &Fabric{
+			Spec: FabricSpec{
+				BrokerConfig: &BrokerConfig{
+					GlobalnetEnable:             true,
+					GlobalnetCIDRRange:          "242.0.0.0/8",
+					DefaultGlobalnetClusterSize: 65536,
+				},
+				JoinConfig: JoinConfig{
+					ClusterID:   "cluster1",
+					ClusterCIDR: "10.244.0.0/16,fd00:10:244::/56",
+					ServiceCIDR: "10.96.0.0/12",
+					NattPort:    4500,
+					IkePort:     500,
+				},
+			},
+		}
+	})
+
+	It("Should accept a valid fabric", func() {
+		Expect(fabric.ValidateCreate()).To(Succeed())
+	})
+
+	It("Should accept a broker only fabric", func() {
+		fabric.Spec.JoinConfig = JoinConfig{}
+		Expect(fabric.ValidateCreate()).To(Succeed())
+	})
+
+	It("Should require the cluster ID of a fabric which does not deploy the broker", func() {
+		fabric.Spec.BrokerConfig = nil
+		fabric.Spec.JoinConfig.ClusterID = ""
+		err := fabric.ValidateCreate()
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.joinConfig.clusterID: Required value"))
+
+		fabric.Spec.Roles = []FabricRole{FabricRoleBroker}
+		Expect(fabric.ValidateCreate()).To(Succeed())
+	})
+
+	It("Should require the cluster ID of a fabric stating the join role", func() {
+		fabric.Spec.Roles = []FabricRole{FabricRoleBroker, FabricRoleJoin}
+		fabric.Spec.JoinConfig.ClusterID = ""
+		err := fabric.ValidateCreate()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("spec.joinConfig.clusterID: Required value"))
+	})
+
+	It("Should reject an invalid cluster ID", func() {
+		fabric.Spec.JoinConfig.ClusterID = "Cluster_1"
+		err := fabric.ValidateCreate()
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.joinConfig.clusterID"))
+	})
+
+	It("Should reject invalid CIDRs and ports", func() {
+		fabric.Spec.JoinConfig.ServiceCIDR = "10.96.0.0/12,10.300.0.0/16"
+		fabric.Spec.JoinConfig.NattPort = 70000
+		err := fabric.ValidateUpdate(fabric.DeepCopy())
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("spec.joinConfig.serviceCIDR"))
+		Expect(err.Error()).To(ContainSubstring("spec.joinConfig.nattPort"))
+	})
+
+	It("Should reject a global CIDR along with a cluster size", func() {
+		fabric.Spec.JoinConfig.GlobalnetCIDR = "242.1.0.0/16"
+		fabric.Spec.JoinConfig.GlobalnetClusterSize = 1024
+		err := fabric.ValidateCreate()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("spec.joinConfig.globalnetClusterSize"))
+	})
+
+	It("Should reject a default cluster size larger than half the supernet", func() {
+		fabric.Spec.BrokerConfig.GlobalnetCIDRRange = "242.0.0.0/16"
+		err := fabric.ValidateCreate()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("cluster size 65536, should be <= 32768"))
+	})
+
+	It("Should accept IPv6 globalnet CIDRs and cluster sizes beyond 32 bits", func() {
+		fabric.Spec.BrokerConfig.GlobalnetCIDRRange = "fd00:242::/48"
+		fabric.Spec.BrokerConfig.DefaultGlobalnetClusterSize = 1 << 40
+		Expect(fabric.ValidateCreate()).To(Succeed())
+
+		fabric.Spec.JoinConfig.GlobalnetCIDR = "fd00:242:0:1::/64"
+		Expect(fabric.ValidateCreate()).To(Succeed())
+	})
+
+	It("Should reject unknown image overrides", func() {
+		fabric.Spec.JoinConfig.ImageOverrideArr = []string{"submariner-gateway=quay.io/gw:dev", "submariner-unknown=x"}
+		err := fabric.ValidateCreate()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("spec.joinConfig.imageOverrideArr[1]"))
+		Expect(err.Error()).NotTo(ContainSubstring("imageOverrideArr[0]"))
+	})
+
+	It("Should require the cluster ID of the managed clusters", func() {
+		fabric.Spec.ManagedClusters = []ManagedCluster{{JoinConfig: JoinConfig{ClusterID: "cluster2"}}, {}}
+		err := fabric.ValidateCreate()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("spec.managedClusters[1].joinConfig.clusterID"))
+	})
+})
@@ -0,0 +1,202 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	operatorv1alpha2 "github.com/DanielXLee/cluster-fabric-operator/api/v1alpha2"
+	consts "github.com/DanielXLee/cluster-fabric-operator/controllers/ensures"
+)
+
+// BrokerReconciler reconciles a Broker object, the broker is deployed on the cluster the object lives in
+type BrokerReconciler struct {
+	client.Client
+	client.Reader
+	*rest.Config
+	Scheme *runtime.Scheme
+
+	// DeployBroker and JoinBroker restrict the roles the operator may perform, the brokers are only deployed when
+	// the operator is permitted to deploy the broker
+	DeployBroker bool
+	JoinBroker   bool
+}
+
+//+kubebuilder:rbac:groups=operator.tkestack.io,resources=brokers,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=operator.tkestack.io,resources=brokers/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=operator.tkestack.io,resources=brokers/finalizers,verbs=update
+
+// Reconcile deploys the broker and joins the managed clusters to it, the same way a fabric deploying the broker is
+// reconciled
+func (r *BrokerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, err error) {
+	klog.Infof("Start reconciling Broker: %s", req.NamespacedName)
+	instance := &operatorv1alpha2.Broker{}
+
+	if err := r.Client.Get(ctx, req.NamespacedName, instance); err != nil {
+		if errors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	// Uninstall the broker when the broker object is being deleted
+	if !instance.ObjectMeta.DeletionTimestamp.IsZero() {
+		klog.Infof("Broker %s is being deleted", req.NamespacedName)
+		return ctrl.Result{}, r.FinalizeBroker(ctx, instance)
+	}
+
+	if !controllerutil.ContainsFinalizer(instance, consts.BrokerFinalizer) {
+		controllerutil.AddFinalizer(instance, consts.BrokerFinalizer)
+		if err := r.Client.Update(ctx, instance); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	fabric := newBrokerFabric(instance)
+	fabricReconciler, rolesErr := r.newFabricReconciler()
+	originalInstance := instance.DeepCopy()
+	// Always attempt to patch the status after each reconciliation.
+	defer func() {
+		setBrokerStatus(instance, fabric)
+		instance.Status.ObservedGeneration = instance.Generation
+		failure := err
+		if failure == nil {
+			// The denied roles are not retried, the permitted roles only change along with the operator flags
+			failure = rolesErr
+		}
+		if failure != nil {
+			instance.Status.Phase = operatorv1alpha2.PhaseFailed
+			instance.Status.Message = failure.Error()
+		} else {
+			instance.Status.Phase = operatorv1alpha2.PhaseRunning
+			instance.Status.Message = ""
+		}
+		if reflect.DeepEqual(originalInstance.Status, instance.Status) {
+			return
+		}
+		if updateErr := r.Status().Update(ctx, instance, &client.UpdateOptions{}); updateErr != nil {
+			klog.Errorf("Update status failed, err: %v", updateErr)
+		}
+	}()
+
+	markRolePermitted(fabric, rolesErr)
+	if err := fabricReconciler.reconcileFabric(fabric); err != nil {
+		return ctrl.Result{}, err
+	}
+	klog.Infof("Finished reconciling Broker: %s", req.NamespacedName)
+	return ctrl.Result{RequeueAfter: resyncPeriod}, nil
+}
+
+// FinalizeBroker uninstalls the managed clusters and the broker, then releases the broker finalizer
+func (r *BrokerReconciler) FinalizeBroker(ctx context.Context, instance *operatorv1alpha2.Broker) error {
+	if !controllerutil.ContainsFinalizer(instance, consts.BrokerFinalizer) {
+		return nil
+	}
+
+	sharedOperator, err := isOperatorShared(ctx, r.Client, instance)
+	if err != nil {
+		return err
+	}
+	// The broker is left in place when the operator is not permitted to deploy it
+	fabricReconciler, _ := r.newFabricReconciler()
+	fabricReconciler.sharedOperator = sharedOperator
+	if err := fabricReconciler.UninstallFabric(newBrokerFabric(instance)); err != nil {
+		return err
+	}
+
+	controllerutil.RemoveFinalizer(instance, consts.BrokerFinalizer)
+	return r.Client.Update(ctx, instance)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *BrokerReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// Refresh the joined clusters of the brokers when a cluster joins, leaves, or updates its gateways
+	mapToAllBrokers := handler.EnqueueRequestsFromMapFunc(func(obj client.Object) []reconcile.Request {
+		brokers := &operatorv1alpha2.BrokerList{}
+		if err := r.Client.List(context.TODO(), brokers); err != nil {
+			klog.Errorf("List brokers failed: %v", err)
+			return nil
+		}
+		requests := make([]reconcile.Request, 0, len(brokers.Items))
+		for _, brokerInstance := range brokers.Items {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+				Name:      brokerInstance.GetName(),
+				Namespace: brokerInstance.GetNamespace(),
+			}})
+		}
+		return requests
+	})
+	b, err := watchJoinedClusters(mgr, r.Scheme,
+		watchLabeledResources(ctrl.NewControllerManagedBy(mgr).For(&operatorv1alpha2.Broker{})), mapToAllBrokers)
+	if err != nil {
+		return err
+	}
+	return b.Complete(r)
+}
+
+// newFabricReconciler returns the fabric reconciler deploying the broker, along with an error when the operator
+// is not permitted to deploy the broker
+func (r *BrokerReconciler) newFabricReconciler() (*FabricReconciler, error) {
+	fabricReconciler := &FabricReconciler{
+		Client:       r.Client,
+		Reader:       r.Reader,
+		Config:       r.Config,
+		Scheme:       r.Scheme,
+		DeployBroker: r.DeployBroker,
+		JoinBroker:   r.JoinBroker,
+	}
+	return fabricReconciler.newPermittedReconciler(true, false)
+}
+
+// newBrokerFabric returns the fabric the broker is reconciled as, with the status the broker was last reconciled
+// with
+func newBrokerFabric(instance *operatorv1alpha2.Broker) *operatorv1alpha2.Fabric {
+	fabric := &operatorv1alpha2.Fabric{
+		ObjectMeta: *instance.ObjectMeta.DeepCopy(),
+	}
+	fabric.Spec.BrokerConfig = instance.Spec.BrokerConfig.DeepCopy()
+	for i := range instance.Spec.ManagedClusters {
+		fabric.Spec.ManagedClusters = append(fabric.Spec.ManagedClusters, *instance.Spec.ManagedClusters[i].DeepCopy())
+	}
+	status := instance.Status.DeepCopy()
+	fabric.Status.Conditions = status.Conditions
+	fabric.Status.ManagedClusters = status.ManagedClusters
+	fabric.Status.JoinedClusters = status.JoinedClusters
+	fabric.Status.GlobalnetCapacity = status.GlobalnetCapacity
+	return fabric
+}
+
+// setBrokerStatus copies the status of the fabric the broker is reconciled as back to the broker
+func setBrokerStatus(instance *operatorv1alpha2.Broker, fabric *operatorv1alpha2.Fabric) {
+	status := fabric.Status.DeepCopy()
+	instance.Status.Conditions = status.Conditions
+	instance.Status.ManagedClusters = status.ManagedClusters
+	instance.Status.JoinedClusters = status.JoinedClusters
+	instance.Status.GlobalnetCapacity = status.GlobalnetCapacity
+}
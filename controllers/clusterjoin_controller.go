@@ -0,0 +1,193 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	operatorv1alpha2 "github.com/DanielXLee/cluster-fabric-operator/api/v1alpha2"
+	consts "github.com/DanielXLee/cluster-fabric-operator/controllers/ensures"
+)
+
+// ClusterJoinReconciler reconciles a ClusterJoin object, the cluster the object lives in is joined to the broker
+type ClusterJoinReconciler struct {
+	client.Client
+	client.Reader
+	*rest.Config
+	Scheme *runtime.Scheme
+
+	// DeployBroker and JoinBroker restrict the roles the operator may perform, the clusters are only joined when
+	// the operator is permitted to join the broker
+	DeployBroker bool
+	JoinBroker   bool
+}
+
+//+kubebuilder:rbac:groups=operator.tkestack.io,resources=clusterjoins,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=operator.tkestack.io,resources=clusterjoins/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=operator.tkestack.io,resources=clusterjoins/finalizers,verbs=update
+
+// Reconcile prepares the cloud and joins the cluster to the broker, the same way a fabric joining the broker is
+// reconciled
+func (r *ClusterJoinReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, err error) {
+	klog.Infof("Start reconciling ClusterJoin: %s", req.NamespacedName)
+	instance := &operatorv1alpha2.ClusterJoin{}
+
+	if err := r.Client.Get(ctx, req.NamespacedName, instance); err != nil {
+		if errors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	// Uninstall submariner when the cluster join is being deleted
+	if !instance.ObjectMeta.DeletionTimestamp.IsZero() {
+		klog.Infof("ClusterJoin %s is being deleted", req.NamespacedName)
+		return ctrl.Result{}, r.FinalizeClusterJoin(ctx, instance)
+	}
+
+	if !controllerutil.ContainsFinalizer(instance, consts.ClusterJoinFinalizer) {
+		controllerutil.AddFinalizer(instance, consts.ClusterJoinFinalizer)
+		if err := r.Client.Update(ctx, instance); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	fabric := newClusterJoinFabric(instance)
+	fabricReconciler, rolesErr := r.newFabricReconciler()
+	originalInstance := instance.DeepCopy()
+	// Always attempt to patch the status after each reconciliation.
+	defer func() {
+		setClusterJoinStatus(instance, fabric)
+		instance.Status.ObservedGeneration = instance.Generation
+		failure := err
+		if failure == nil {
+			// The denied roles are not retried, the permitted roles only change along with the operator flags
+			failure = rolesErr
+		}
+		if failure != nil {
+			instance.Status.Phase = operatorv1alpha2.PhaseFailed
+			instance.Status.Message = failure.Error()
+		} else {
+			instance.Status.Phase = operatorv1alpha2.PhaseRunning
+			instance.Status.Message = ""
+		}
+		if reflect.DeepEqual(originalInstance.Status, instance.Status) {
+			return
+		}
+		if updateErr := r.Status().Update(ctx, instance, &client.UpdateOptions{}); updateErr != nil {
+			klog.Errorf("Update status failed, err: %v", updateErr)
+		}
+	}()
+
+	markRolePermitted(fabric, rolesErr)
+	if err := fabricReconciler.reconcileFabric(fabric); err != nil {
+		return ctrl.Result{}, err
+	}
+	klog.Infof("Finished reconciling ClusterJoin: %s", req.NamespacedName)
+	return ctrl.Result{RequeueAfter: resyncPeriod}, nil
+}
+
+// FinalizeClusterJoin uninstalls submariner from the cluster and the broker, then releases the cluster join
+// finalizer
+func (r *ClusterJoinReconciler) FinalizeClusterJoin(ctx context.Context, instance *operatorv1alpha2.ClusterJoin) error {
+	if !controllerutil.ContainsFinalizer(instance, consts.ClusterJoinFinalizer) {
+		return nil
+	}
+
+	sharedOperator, err := isOperatorShared(ctx, r.Client, instance)
+	if err != nil {
+		return err
+	}
+	// Submariner is left in place when the operator is not permitted to join the cluster
+	fabricReconciler, _ := r.newFabricReconciler()
+	fabricReconciler.sharedOperator = sharedOperator
+	if err := fabricReconciler.UninstallFabric(newClusterJoinFabric(instance)); err != nil {
+		return err
+	}
+
+	controllerutil.RemoveFinalizer(instance, consts.ClusterJoinFinalizer)
+	return r.Client.Update(ctx, instance)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ClusterJoinReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// Rejoin the clusters with the new PSK as soon as the broker deployed on this cluster rotates it
+	mapToAllClusterJoins := handler.EnqueueRequestsFromMapFunc(func(obj client.Object) []reconcile.Request {
+		clusterJoins := &operatorv1alpha2.ClusterJoinList{}
+		if err := r.Client.List(context.TODO(), clusterJoins); err != nil {
+			klog.Errorf("List cluster joins failed: %v", err)
+			return nil
+		}
+		requests := make([]reconcile.Request, 0, len(clusterJoins.Items))
+		for _, clusterJoin := range clusterJoins.Items {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+				Name:      clusterJoin.GetName(),
+				Namespace: clusterJoin.GetNamespace(),
+			}})
+		}
+		return requests
+	})
+	b := watchLabeledResources(ctrl.NewControllerManagedBy(mgr).For(&operatorv1alpha2.ClusterJoin{}))
+	return watchIPSecPSK(b, mapToAllClusterJoins).Complete(r)
+}
+
+// newFabricReconciler returns the fabric reconciler joining the cluster, along with an error when the operator is
+// not permitted to join the broker
+func (r *ClusterJoinReconciler) newFabricReconciler() (*FabricReconciler, error) {
+	fabricReconciler := &FabricReconciler{
+		Client:       r.Client,
+		Reader:       r.Reader,
+		Config:       r.Config,
+		Scheme:       r.Scheme,
+		DeployBroker: r.DeployBroker,
+		JoinBroker:   r.JoinBroker,
+	}
+	return fabricReconciler.newPermittedReconciler(false, true)
+}
+
+// newClusterJoinFabric returns the fabric the cluster join is reconciled as, with the status the cluster join was
+// last reconciled with
+func newClusterJoinFabric(instance *operatorv1alpha2.ClusterJoin) *operatorv1alpha2.Fabric {
+	fabric := &operatorv1alpha2.Fabric{
+		ObjectMeta: *instance.ObjectMeta.DeepCopy(),
+	}
+	instance.Spec.JoinConfig.DeepCopyInto(&fabric.Spec.JoinConfig)
+	instance.Spec.CloudPrepareConfig.DeepCopyInto(&fabric.Spec.CloudPrepareConfig)
+	status := instance.Status.DeepCopy()
+	fabric.Status.Network = status.Network
+	fabric.Status.Conditions = status.Conditions
+	return fabric
+}
+
+// setClusterJoinStatus copies the status of the fabric the cluster join is reconciled as back to the cluster join
+func setClusterJoinStatus(instance *operatorv1alpha2.ClusterJoin, fabric *operatorv1alpha2.Fabric) {
+	status := fabric.Status.DeepCopy()
+	instance.Status.Network = status.Network
+	instance.Status.Conditions = status.Conditions
+}
@@ -17,6 +17,10 @@ limitations under the License.
 package controllers
 
 import (
+	"fmt"
+	"math"
+	"math/big"
+
 	submarinerv1a1 "github.com/submariner-io/submariner-operator/apis/submariner/v1alpha1"
 	"k8s.io/klog/v2"
 
@@ -25,16 +29,17 @@ import (
 	"github.com/DanielXLee/cluster-fabric-operator/controllers/discovery/globalnet"
 	"github.com/DanielXLee/cluster-fabric-operator/controllers/ensures/broker"
 	"github.com/DanielXLee/cluster-fabric-operator/controllers/ensures/operator/brokercr"
+	"github.com/DanielXLee/cluster-fabric-operator/controllers/metrics"
 
-	operatorv1alpha1 "github.com/DanielXLee/cluster-fabric-operator/api/v1alpha1"
+	operatorv1alpha2 "github.com/DanielXLee/cluster-fabric-operator/api/v1alpha2"
 	"github.com/DanielXLee/cluster-fabric-operator/controllers/ensures/operator/submarinerop"
 )
 
 // var defaultComponents = []string{components.ServiceDiscovery, components.Connectivity}
 // var validComponents = []string{components.ServiceDiscovery, components.Connectivity, components.Globalnet, components.Broker}
 
-func (r *FabricReconciler) DeploySubmerinerBroker(instance *operatorv1alpha1.Fabric) error {
-	brokerConfig := &instance.Spec.BrokerConfig
+func (r *FabricReconciler) DeploySubmerinerBroker(instance *operatorv1alpha2.Fabric) error {
+	brokerConfig := instance.Spec.GetBrokerConfig()
 
 	// if err := isValidComponents(instance); err != nil {
 	// 	klog.Errorf("Invalid components parameter: %v", err)
@@ -43,46 +48,126 @@ func (r *FabricReconciler) DeploySubmerinerBroker(instance *operatorv1alpha1.Fab
 
 	if valid, err := isValidGlobalnetConfig(instance); !valid {
 		klog.Errorf("Invalid GlobalCIDR configuration: %v", err)
+		markStageFailed(instance, operatorv1alpha2.ConditionBrokerReady, operatorv1alpha2.ReasonInvalidConfig,
+			fmt.Errorf("invalid GlobalCIDR configuration: %v", err))
 		return err
 	}
 
 	klog.Info("Setting up broker RBAC")
-	if err := broker.Ensure(r.Client, r.Config, brokerConfig.ServiceDiscoveryEnabled, brokerConfig.GlobalnetEnable, false); err != nil {
+	serviceDiscoveryEnabled := operatorv1alpha2.IsEnabled(brokerConfig.ServiceDiscoveryEnabled)
+	if err := broker.Ensure(r.Client, r.Config, serviceDiscoveryEnabled, brokerConfig.GlobalnetEnable, false); err != nil {
 		klog.Errorf("Error setting up broker RBAC: %v", err)
+		markStageFailed(instance, operatorv1alpha2.ConditionBrokerReady, operatorv1alpha2.ReasonFailed,
+			fmt.Errorf("error setting up broker RBAC: %v", err))
 		return err
 	}
 	klog.Info("Deploying the Submariner operator")
 	if err := submarinerop.Ensure(r.Client, r.Config, true); err != nil {
 		klog.Errorf("Error deploying the operator: %v", err)
+		markStageFailed(instance, operatorv1alpha2.ConditionOperatorDeployed, operatorv1alpha2.ReasonFailed, err)
 		return err
 	}
+	markStageSucceeded(instance, operatorv1alpha2.ConditionOperatorDeployed, "The submariner operator is deployed")
+
 	klog.Info("Deploying the broker")
 	if err := brokercr.Ensure(r.Client, populateBrokerSpec(instance)); err != nil {
 		klog.Errorf("Broker deployment failed: %v", err)
+		markStageFailed(instance, operatorv1alpha2.ConditionBrokerReady, operatorv1alpha2.ReasonFailed,
+			fmt.Errorf("broker deployment failed: %v", err))
 		return err
 	}
 
 	if brokerConfig.GlobalnetEnable {
 		if err := globalnet.ValidateExistingGlobalNetworks(r.Reader, consts.SubmarinerBrokerNamespace); err != nil {
 			klog.Errorf("Error validating existing globalCIDR configmap: %v", err)
+			markStageFailed(instance, operatorv1alpha2.ConditionBrokerReady, operatorv1alpha2.ReasonInvalidConfig,
+				fmt.Errorf("error validating existing globalCIDR configmap: %v", err))
 			return err
 		}
 	}
 
+	if err := broker.MigrateGlobalnetConfigMap(r.Client, r.Reader, consts.SubmarinerBrokerNamespace); err != nil {
+		klog.Errorf("Error migrating the globalCIDR configmap on Broker: %v", err)
+		markStageFailed(instance, operatorv1alpha2.ConditionBrokerReady, operatorv1alpha2.ReasonFailed,
+			fmt.Errorf("error migrating the globalCIDR configmap on Broker: %v", err))
+		return err
+	}
+
 	if err := broker.CreateGlobalnetConfigMap(r.Client, brokerConfig.GlobalnetEnable, brokerConfig.GlobalnetCIDRRange,
 		brokerConfig.DefaultGlobalnetClusterSize, consts.SubmarinerBrokerNamespace); err != nil {
 		klog.Errorf("Error creating globalCIDR configmap on Broker: %v", err)
+		markStageFailed(instance, operatorv1alpha2.ConditionBrokerReady, operatorv1alpha2.ReasonFailed,
+			fmt.Errorf("error creating globalCIDR configmap on Broker: %v", err))
 		return err
 	}
 
+	if brokerConfig.GlobalnetEnable {
+		if err := globalnet.AllocateJoinedClusters(r.Client, r.Reader, consts.SubmarinerBrokerNamespace); err != nil {
+			klog.Errorf("Error allocating global CIDRs to the joined clusters: %v", err)
+			markStageFailed(instance, operatorv1alpha2.ConditionBrokerReady, operatorv1alpha2.ReasonFailed,
+				fmt.Errorf("error allocating global CIDRs to the joined clusters: %v", err))
+			return err
+		}
+
+		gracePeriod := broker.DefaultGlobalCIDRReleaseGracePeriod
+		if brokerConfig.GlobalCIDRReleaseGracePeriod != nil {
+			gracePeriod = brokerConfig.GlobalCIDRReleaseGracePeriod.Duration
+		}
+		if err := broker.CollectGlobalCIDRAllocations(r.Client, r.Reader, consts.SubmarinerBrokerNamespace, gracePeriod); err != nil {
+			klog.Errorf("Error reclaiming the global CIDRs of departed clusters: %v", err)
+			markStageFailed(instance, operatorv1alpha2.ConditionBrokerReady, operatorv1alpha2.ReasonFailed,
+				fmt.Errorf("error reclaiming the global CIDRs of departed clusters: %v", err))
+			return err
+		}
+	}
+
 	if err := broker.CreateBrokerInfoConfigMap(r.Client, r.Config, instance); err != nil {
 		klog.Errorf("Error writing the broker information: %v", err)
+		markStageFailed(instance, operatorv1alpha2.ConditionBrokerReady, operatorv1alpha2.ReasonFailed,
+			fmt.Errorf("error writing the broker information: %v", err))
 		return err
 	}
+	markStageSucceeded(instance, operatorv1alpha2.ConditionBrokerReady, "The broker is deployed")
 	return nil
 }
 
-// func isValidComponents(instance *operatorv1alpha1.Fabric) error {
+// UpdateGlobalnetCapacity reports how much of the globalnet supernet is allocated, in the status and the metrics
+func (r *FabricReconciler) UpdateGlobalnetCapacity(instance *operatorv1alpha2.Fabric) error {
+	if !instance.Spec.GetBrokerConfig().GlobalnetEnable {
+		instance.Status.GlobalnetCapacity = nil
+		metrics.RecordGlobalnetCapacity(nil)
+		return nil
+	}
+
+	globalnetInfo, _, err := globalnet.GetGlobalNetworks(r.Reader, consts.SubmarinerBrokerNamespace)
+	if err != nil {
+		return err
+	}
+	capacity, err := globalnet.GetSupernetCapacity(globalnetInfo)
+	if err != nil {
+		return err
+	}
+	instance.Status.GlobalnetCapacity = &operatorv1alpha2.GlobalnetCapacity{
+		CIDRRange:            capacity.CIDRRange,
+		TotalIPs:             capInt64(capacity.TotalIPs),
+		AllocatedBlocks:      int32(capacity.AllocatedBlocks),
+		AllocatedIPs:         capInt64(capacity.AllocatedIPs),
+		FreeIPs:              capInt64(capacity.FreeIPs),
+		LargestFreeBlockIPs:  capInt64(capacity.LargestFreeIPs),
+		FragmentationPercent: int32(math.Round(capacity.Fragmentation * 100)),
+	}
+	metrics.RecordGlobalnetCapacity(capacity)
+	return nil
+}
+
+func capInt64(value *big.Int) int64 {
+	if !value.IsInt64() {
+		return math.MaxInt64
+	}
+	return value.Int64()
+}
+
+// func isValidComponents(instance *operatorv1alpha2.Fabric) error {
 // 	componentSet := stringset.New(instance.Spec.BrokerConfig.ComponentArr...)
 // 	validComponentSet := stringset.New(validComponents...)
 
@@ -100,8 +185,8 @@ func (r *FabricReconciler) DeploySubmerinerBroker(instance *operatorv1alpha1.Fab
 // 	return nil
 // }
 
-func isValidGlobalnetConfig(instance *operatorv1alpha1.Fabric) (bool, error) {
-	brokerConfig := &instance.Spec.BrokerConfig
+func isValidGlobalnetConfig(instance *operatorv1alpha2.Fabric) (bool, error) {
+	brokerConfig := instance.Spec.GetBrokerConfig()
 	var err error
 	if !brokerConfig.GlobalnetEnable {
 		return true, nil
@@ -113,8 +198,8 @@ func isValidGlobalnetConfig(instance *operatorv1alpha1.Fabric) (bool, error) {
 	return true, err
 }
 
-func populateBrokerSpec(instance *operatorv1alpha1.Fabric) submarinerv1a1.BrokerSpec {
-	brokerConfig := instance.Spec.BrokerConfig
+func populateBrokerSpec(instance *operatorv1alpha2.Fabric) submarinerv1a1.BrokerSpec {
+	brokerConfig := instance.Spec.GetBrokerConfig()
 	brokerSpec := submarinerv1a1.BrokerSpec{
 		GlobalnetEnabled:            brokerConfig.GlobalnetEnable,
 		GlobalnetCIDRRange:          brokerConfig.GlobalnetCIDRRange,
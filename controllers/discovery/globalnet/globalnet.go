@@ -17,15 +17,22 @@ limitations under the License.
 package globalnet
 
 import (
-	"encoding/binary"
+	"context"
 	"encoding/json"
 	"fmt"
+	"math/big"
 	"math/bits"
 	"net"
+	"sort"
+	"strings"
+	"sync"
 
+	submarinerv1 "github.com/submariner-io/submariner/pkg/apis/submariner.io/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/util/retry"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -44,31 +51,32 @@ type GlobalNetwork struct {
 	ClusterID   string
 }
 
-type GlobalCIDR struct {
-	cidr              string
+// Allocator hands out non-overlapping global CIDRs from a supernet, it is safe for concurrent use. It only knows the
+// allocations it was given: the joins building their own allocator from the allocations recorded on the broker rely
+// on the broker rejecting the second record of an overlapping allocation.
+type Allocator struct {
+	mutex             sync.Mutex
 	net               *net.IPNet
 	allocatedClusters []*CIDR
-	allocatedCount    int
 }
 
 type CIDR struct {
 	network *net.IPNet
 	size    int
-	lastIP  uint
+	lastIP  *big.Int
 }
 
 type Config struct {
 	ClusterCIDR             string
 	ClusterID               string
 	GlobalnetCIDR           string
+	GlobalCIDRs             []string
 	ServiceCIDR             string
 	GlobalnetClusterSize    uint
 	ClusterCIDRAutoDetected bool
 	ServiceCIDRAutoDetected bool
 }
 
-var globalCidr = GlobalCIDR{allocatedCount: 0}
-
 func isOverlappingCIDR(cidrList []string, cidr string) (bool, error) {
 	_, newNet, err := net.ParseCIDR(cidr)
 	if err != nil {
@@ -98,30 +106,70 @@ func NewCIDR(cidr string) (CIDR, error) {
 	return clusterCidr, nil
 }
 
-func LastIP(network *net.IPNet) uint {
+// LastIP returns the last IP of the network as an integer, wide enough for IPv6 networks
+func LastIP(network *net.IPNet) *big.Int {
 	ones, total := network.Mask.Size()
 	clusterSize := uint(total - ones)
-	firstIPInt := ipToUint(network.IP)
-	lastIPUint := (firstIPInt + 1<<clusterSize) - 1
-	return lastIPUint
+	lastIP := new(big.Int).Lsh(big.NewInt(1), clusterSize)
+	lastIP.Add(lastIP, ipToInt(network.IP))
+	return lastIP.Sub(lastIP, big.NewInt(1))
+}
+
+// NewAllocator returns an allocator of the supernet with nothing allocated yet
+func NewAllocator(supernet string) (*Allocator, error) {
+	_, network, err := net.ParseCIDR(supernet)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GlobalCIDR %s configured", supernet)
+	}
+	return &Allocator{net: network}, nil
+}
+
+// Reserve marks CIDRs allocated elsewhere as in use, without checking them against the supernet or each other
+func (a *Allocator) Reserve(cidrs ...string) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	for _, cidr := range cidrs {
+		clusterCidr, err := NewCIDR(cidr)
+		if err != nil {
+			return err
+		}
+		a.allocatedClusters = append(a.allocatedClusters, &clusterCidr)
+	}
+	return nil
+}
+
+// AllocateCIDR allocates the requested CIDR, which must lie within the supernet and not overlap an allocated one
+func (a *Allocator) AllocateCIDR(cidr string) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	_, err := a.allocateByCidr(cidr)
+	return err
+}
+
+// Allocate allocates the first free CIDR holding clusterSize IPs
+func (a *Allocator) Allocate(clusterSize uint) (string, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	return a.allocateByClusterSize(clusterSize)
 }
 
-func allocateByCidr(cidr string) (uint, error) {
+// allocateByCidr returns, along with the error of an unavailable CIDR, the last IP of the allocated CIDR it
+// overlaps so the next candidate can start after it, or nil when no candidate after it can fit
+func (a *Allocator) allocateByCidr(cidr string) (*big.Int, error) {
 	requestedIP, requestedNetwork, err := net.ParseCIDR(cidr)
-	if err != nil || !globalCidr.net.Contains(requestedIP) {
-		return 0, fmt.Errorf("%s not a valid subnet of %v", cidr, globalCidr.net)
+	if err != nil || !a.net.Contains(requestedIP) {
+		return nil, fmt.Errorf("%s not a valid subnet of %v", cidr, a.net)
 	}
 
 	var clusterCidr CIDR
 	if clusterCidr, err = NewCIDR(cidr); err != nil {
-		return 0, err
+		return nil, err
 	}
 
-	if !globalCidr.net.Contains(uintToIP(clusterCidr.lastIP)) {
-		return 0, fmt.Errorf("%s not a valid subnet of %v", cidr, globalCidr.net)
+	if !a.net.Contains(intToIP(clusterCidr.lastIP, isIPv6(a.net.IP))) {
+		return nil, fmt.Errorf("%s not a valid subnet of %v", cidr, a.net)
 	}
-	for i := 0; i < globalCidr.allocatedCount; i++ {
-		allocated := globalCidr.allocatedClusters[i]
+	for _, allocated := range a.allocatedClusters {
 		if allocated.network.Contains(requestedIP) {
 			// subset of already allocated, try next
 			return allocated.lastIP, fmt.Errorf("%s subset of already allocated globalCidr %v", cidr, allocated.network)
@@ -131,69 +179,93 @@ func allocateByCidr(cidr string) (uint, error) {
 			return clusterCidr.lastIP, fmt.Errorf("%s overlaps with already allocated globalCidr %s", cidr, allocated.network)
 		}
 	}
-	globalCidr.allocatedClusters = append(globalCidr.allocatedClusters, &clusterCidr)
-	globalCidr.allocatedCount++
-	return 0, nil
+	a.allocatedClusters = append(a.allocatedClusters, &clusterCidr)
+	return nil, nil
 }
 
-func allocateByClusterSize(numSize uint) (string, error) {
+func (a *Allocator) allocateByClusterSize(numSize uint) (string, error) {
 	bitSize := bits.LeadingZeros(0) - bits.LeadingZeros(numSize-1)
-	_, totalbits := globalCidr.net.Mask.Size()
+	_, totalbits := a.net.Mask.Size()
 	clusterPrefix := totalbits - bitSize
 	mask := net.CIDRMask(clusterPrefix, totalbits)
 
-	cidr := fmt.Sprintf("%s/%d", globalCidr.net.IP, clusterPrefix)
+	cidr := fmt.Sprintf("%s/%d", a.net.IP, clusterPrefix)
 
-	last, err := allocateByCidr(cidr)
-	if err != nil && last == 0 {
+	last, err := a.allocateByCidr(cidr)
+	if err != nil && last == nil {
 		return "", err
 	}
 	for err != nil {
 		nextNet := net.IPNet{
-			IP:   uintToIP(last + 1),
+			IP:   intToIP(new(big.Int).Add(last, big.NewInt(1)), isIPv6(a.net.IP)),
 			Mask: mask,
 		}
 		cidr = nextNet.String()
-		last, err = allocateByCidr(cidr)
-		if err != nil && last == 0 {
+		last, err = a.allocateByCidr(cidr)
+		if err != nil && last == nil {
 			return "", fmt.Errorf("allocation not available")
 		}
 	}
 	return cidr, nil
 }
 
-func AllocateGlobalCIDR(globalnetInfo *GlobalnetInfo) (string, error) {
-	globalCidr = GlobalCIDR{allocatedCount: 0, cidr: globalnetInfo.GlobalnetCidrRange}
-	_, network, err := net.ParseCIDR(globalCidr.cidr)
+// NewAllocatorFor returns an allocator of the globalnet supernet with the CIDRs of every other cluster reserved
+func NewAllocatorFor(globalnetInfo *GlobalnetInfo, clusterID string) (*Allocator, error) {
+	allocator, err := NewAllocator(globalnetInfo.GlobalnetCidrRange)
 	if err != nil {
-		return "", fmt.Errorf("invalid GlobalCIDR %s configured", globalCidr.cidr)
+		return nil, err
 	}
-	globalCidr.net = network
-	for _, globalNetwork := range globalnetInfo.GlobalCidrInfo {
-		for _, otherCluster := range globalNetwork.GlobalCIDRs {
-			otherClusterCIDR, err := NewCIDR(otherCluster)
-			if err != nil {
-				return "", err
-			}
-			globalCidr.allocatedClusters = append(globalCidr.allocatedClusters, &otherClusterCIDR)
-			globalCidr.allocatedCount++
+	for otherClusterID, globalNetwork := range globalnetInfo.GlobalCidrInfo {
+		if otherClusterID == clusterID {
+			continue
+		}
+		if err := allocator.Reserve(globalNetwork.GlobalCIDRs...); err != nil {
+			return nil, err
 		}
 	}
-	return allocateByClusterSize(globalnetInfo.GlobalnetClusterSize)
+	return allocator, nil
 }
 
-func ipToUint(ip net.IP) uint {
-	intIP := ip
-	if len(ip) == 16 {
-		intIP = ip[12:16]
+func AllocateGlobalCIDR(globalnetInfo *GlobalnetInfo) (string, error) {
+	allocator, err := NewAllocatorFor(globalnetInfo, "")
+	if err != nil {
+		return "", err
 	}
-	return uint(binary.BigEndian.Uint32(intIP))
+	return allocator.Allocate(globalnetInfo.GlobalnetClusterSize)
 }
 
-func uintToIP(ip uint) net.IP {
-	netIP := make(net.IP, 4)
-	binary.BigEndian.PutUint32(netIP, uint32(ip))
-	return netIP
+func isIPv6(ip net.IP) bool {
+	return ip.To4() == nil
+}
+
+func ipToInt(ip net.IP) *big.Int {
+	if ipv4 := ip.To4(); ipv4 != nil {
+		return new(big.Int).SetBytes(ipv4)
+	}
+	return new(big.Int).SetBytes(ip.To16())
+}
+
+// intToIP returns nil for an integer past the end of the address space, no network contains it
+func intToIP(ip *big.Int, ipv6 bool) net.IP {
+	netIP := make(net.IP, net.IPv4len)
+	if ipv6 {
+		netIP = make(net.IP, net.IPv6len)
+	}
+	if ip.BitLen() > len(netIP)*8 {
+		return nil
+	}
+	return ip.FillBytes(netIP)
+}
+
+// maxClusterSize is the largest cluster size, IPv6 blocks can hold more IPs
+const maxClusterSize = ^uint(0)
+
+// blockSize returns the amount of IPs of a block with that many host bits, capped to the largest cluster size
+func blockSize(hostBits int) uint {
+	if hostBits >= bits.UintSize {
+		return maxClusterSize
+	}
+	return 1 << uint(hostBits)
 }
 
 func GetValidClusterSize(cidrRange string, clusterSize uint) (uint, error) {
@@ -202,25 +274,26 @@ func GetValidClusterSize(cidrRange string, clusterSize uint) (uint, error) {
 		return 0, err
 	}
 	ones, totalbits := network.Mask.Size()
-	availableSize := 1 << uint(totalbits-ones)
+	availableSize := blockSize(totalbits - ones - 1)
 	userClusterSize := clusterSize
-	clusterSize = nextPowerOf2(uint32(clusterSize))
-	if clusterSize > uint(availableSize/2) {
-		return 0, fmt.Errorf("cluster size %d, should be <= %d", userClusterSize, availableSize/2)
+	clusterSize = nextPowerOf2(clusterSize)
+	if clusterSize > availableSize {
+		return 0, fmt.Errorf("cluster size %d, should be <= %d", userClusterSize, availableSize)
 	}
 	return clusterSize, nil
 }
 
 //Refer: https://graphics.stanford.edu/~seander/bithacks.html#RoundUpPowerOf2
-func nextPowerOf2(n uint32) uint {
+func nextPowerOf2(n uint) uint {
 	n--
 	n |= n >> 1
 	n |= n >> 2
 	n |= n >> 4
 	n |= n >> 8
 	n |= n >> 16
+	n |= n >> 32
 	n++
-	return uint(n)
+	return n
 }
 
 func CheckOverlappingCidrs(globalnetInfo *GlobalnetInfo, netconfig Config) error {
@@ -240,6 +313,51 @@ func CheckOverlappingCidrs(globalnetInfo *GlobalnetInfo, netconfig Config) error
 	return nil
 }
 
+// FindOverlappingClusters returns the overlaps between the pod and service CIDRs of the cluster and the ones the
+// other clusters published to the broker in their Cluster objects
+func FindOverlappingClusters(reader client.Reader, brokerNamespace string, netconfig Config) ([]string, error) {
+	clusters := &submarinerv1.ClusterList{}
+	if err := reader.List(context.TODO(), clusters, client.InNamespace(brokerNamespace)); err != nil {
+		if meta.IsNoMatchError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	ownCIDRs := map[string][]string{
+		"pod CIDR":     splitCIDRs(netconfig.ClusterCIDR),
+		"service CIDR": splitCIDRs(netconfig.ServiceCIDR),
+	}
+	overlaps := []string{}
+	for i := range clusters.Items {
+		cluster := &clusters.Items[i]
+		if cluster.Spec.ClusterID == netconfig.ClusterID {
+			continue
+		}
+		otherCIDRs := append(append([]string{}, cluster.Spec.ClusterCIDR...), cluster.Spec.ServiceCIDR...)
+		for _, kind := range []string{"pod CIDR", "service CIDR"} {
+			for _, cidr := range ownCIDRs[kind] {
+				overlap, err := isOverlappingCIDR(otherCIDRs, cidr)
+				if err != nil {
+					return nil, fmt.Errorf("unable to validate overlapping CIDR: %s", err)
+				}
+				if overlap {
+					overlaps = append(overlaps, fmt.Sprintf("%s %s overlaps the CIDRs %v of cluster %q",
+						kind, cidr, otherCIDRs, cluster.Spec.ClusterID))
+				}
+			}
+		}
+	}
+	return overlaps, nil
+}
+
+func splitCIDRs(cidrs string) []string {
+	if cidrs == "" {
+		return nil
+	}
+	return strings.Split(cidrs, ",")
+}
+
 func isCIDRPreConfigured(clusterID string, globalNetworks map[string]*GlobalNetwork) bool {
 	// GlobalCIDR is not pre-configured
 	if globalNetworks[clusterID] == nil || globalNetworks[clusterID].GlobalCIDRs == nil || len(globalNetworks[clusterID].GlobalCIDRs) == 0 {
@@ -311,10 +429,8 @@ func GetGlobalNetworks(reader client.Reader, brokerNamespace string) (*Globalnet
 		}
 	}
 
-	var clusterInfo []broker.ClusterInfo
-	err = json.Unmarshal([]byte(configMap.Data[broker.ClusterInfoKey]), &clusterInfo)
+	clusterInfo, err := broker.ListClusterInfos(reader, brokerNamespace)
 	if err != nil {
-		klog.Errorf("error reading globalnet clusterInfo: %v", err)
 		return nil, nil, err
 	}
 
@@ -331,43 +447,126 @@ func GetGlobalNetworks(reader client.Reader, brokerNamespace string) (*Globalnet
 	return &globalnetInfo, configMap, nil
 }
 
-func AssignGlobalnetIPs(globalnetInfo *GlobalnetInfo, netconfig Config) (string, error) {
+// AssignGlobalnetIPs returns the global CIDRs of the cluster, allocating its first one on its first join. A cluster
+// which already has global CIDRs and requests a larger cluster size gets an additional block for the missing IPs.
+func AssignGlobalnetIPs(globalnetInfo *GlobalnetInfo, netconfig Config) ([]string, error) {
 	klog.Info("Assigning Globalnet IPs")
 	globalnetCIDR := netconfig.GlobalnetCIDR
 	clusterID := netconfig.ClusterID
-	var err error
+	allocator, err := NewAllocatorFor(globalnetInfo, clusterID)
+	if err != nil {
+		klog.Errorf("globalnet failed: %v", err)
+		return nil, err
+	}
+
+	if isCIDRPreConfigured(clusterID, globalnetInfo.GlobalCidrInfo) {
+		// globalCidr already configured on this cluster, whether the user specified one or not
+		preConfigured := globalnetInfo.GlobalCidrInfo[clusterID].GlobalCIDRs
+		if globalnetCIDR == "" {
+			klog.Infof("Cluster already has GlobalCIDRs allocated: %v", preConfigured)
+		} else {
+			klog.Infof("Pre-configured GlobalCIDRs %v detected. Not changing them.", preConfigured)
+		}
+		if netconfig.GlobalnetClusterSize == 0 {
+			return preConfigured, nil
+		}
+		return expandGlobalCIDRs(allocator, preConfigured, globalnetInfo.GlobalnetClusterSize)
+	}
+
 	if globalnetCIDR == "" {
 		// Globalnet enabled, GlobalCIDR not specified by the user
-		if isCIDRPreConfigured(clusterID, globalnetInfo.GlobalCidrInfo) {
-			// globalCidr already configured on this cluster
-			globalnetCIDR = globalnetInfo.GlobalCidrInfo[clusterID].GlobalCIDRs[0]
-			klog.Infof("Cluster already has GlobalCIDR allocated: %s", globalnetCIDR)
-		} else {
-			// no globalCidr configured on this cluster
-			globalnetCIDR, err = AllocateGlobalCIDR(globalnetInfo)
-			if err != nil {
-				klog.Errorf("globalnet failed: %v", err)
-				return "", err
-			}
-			klog.Infof("Allocated GlobalCIDR: %s", globalnetCIDR)
+		globalnetCIDR, err = allocator.Allocate(globalnetInfo.GlobalnetClusterSize)
+		if err != nil {
+			klog.Errorf("globalnet failed: %v", err)
+			return nil, err
 		}
+		klog.Infof("Allocated GlobalCIDR: %s", globalnetCIDR)
 	} else {
 		// Globalnet enabled, globalnetCIDR specified by user
-		if isCIDRPreConfigured(clusterID, globalnetInfo.GlobalCidrInfo) {
-			// globalCidr pre-configured on this cluster
-			globalnetCIDR = globalnetInfo.GlobalCidrInfo[clusterID].GlobalCIDRs[0]
-			klog.Infof("Pre-configured GlobalCIDR %s detected. Not changing it.", globalnetCIDR)
-		} else {
-			// globalCidr as specified by the user
-			err := CheckOverlappingCidrs(globalnetInfo, netconfig)
+		if err := allocator.AllocateCIDR(globalnetCIDR); err != nil {
+			klog.Errorf("error validating overlapping GlobalCIDRs %s: %v", globalnetCIDR, err)
+			return nil, err
+		}
+		klog.Infof("GlobalCIDR is: %s", globalnetCIDR)
+	}
+	return []string{globalnetCIDR}, nil
+}
+
+// expandGlobalCIDRs appends a block holding the IPs the global CIDRs lack to reach the cluster size, if any
+func expandGlobalCIDRs(allocator *Allocator, globalCIDRs []string, clusterSize uint) ([]string, error) {
+	if err := allocator.Reserve(globalCIDRs...); err != nil {
+		return nil, err
+	}
+	size, err := ClusterSizeOf(globalCIDRs)
+	if err != nil {
+		return nil, err
+	}
+	if size >= clusterSize {
+		return globalCIDRs, nil
+	}
+
+	cidr, err := allocator.Allocate(nextPowerOf2(clusterSize - size))
+	if err != nil {
+		klog.Errorf("unable to expand the GlobalCIDRs %v to %d IPs: %v", globalCIDRs, clusterSize, err)
+		return nil, err
+	}
+	klog.Infof("Allocated additional GlobalCIDR %s to reach %d IPs", cidr, clusterSize)
+	expanded := make([]string, 0, len(globalCIDRs)+1)
+	return append(append(expanded, globalCIDRs...), cidr), nil
+}
+
+// ClusterSizeOf returns the amount of global IPs in the global CIDRs
+func ClusterSizeOf(globalCIDRs []string) (uint, error) {
+	var size uint
+	for _, cidr := range globalCIDRs {
+		clusterCidr, err := NewCIDR(cidr)
+		if err != nil {
+			return 0, err
+		}
+		block := blockSize(clusterCidr.size)
+		if size > maxClusterSize-block {
+			return maxClusterSize, nil
+		}
+		size += block
+	}
+	return size, nil
+}
+
+// AllocateJoinedClusters allocates global CIDRs of the default cluster size to the clusters which joined the broker
+// before globalnet was enabled, they pick them up when they redeploy with globalnet
+func AllocateJoinedClusters(c client.Client, reader client.Reader, brokerNamespace string) error {
+	joinedClusters, err := broker.ListJoinedClusters(reader, brokerNamespace)
+	if err != nil {
+		return err
+	}
+	for i := range joinedClusters {
+		clusterID := joinedClusters[i].ClusterID
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			globalnetInfo, configMap, err := GetGlobalNetworks(reader, brokerNamespace)
+			if err != nil {
+				return err
+			}
+			if !globalnetInfo.GlobalnetEnabled || isCIDRPreConfigured(clusterID, globalnetInfo.GlobalCidrInfo) {
+				return nil
+			}
+			allocator, err := NewAllocatorFor(globalnetInfo, clusterID)
+			if err != nil {
+				return err
+			}
+			globalCIDR, err := allocator.Allocate(globalnetInfo.GlobalnetClusterSize)
 			if err != nil {
-				klog.Errorf("error validating overlapping GlobalCIDRs %s: %v", globalnetCIDR, err)
-				return "", err
+				return err
 			}
-			klog.Infof("GlobalCIDR is: %s", globalnetCIDR)
+			klog.Infof("Allocated GlobalCIDR %s to the joined cluster %s", globalCIDR, clusterID)
+			cluster := broker.ClusterInfo{ClusterID: clusterID, GlobalCidr: []string{globalCIDR}}
+			return broker.RecordGlobalCIDRs(c, reader, brokerNamespace, configMap, cluster, nil)
+		})
+		if err != nil {
+			klog.Errorf("Unable to allocate a GlobalCIDR to the joined cluster %s: %v", clusterID, err)
+			return err
 		}
 	}
-	return globalnetCIDR, nil
+	return nil
 }
 
 func IsValidCIDR(cidr string) error {
@@ -409,3 +608,91 @@ func ValidateExistingGlobalNetworks(reader client.Reader, namespace string) erro
 	}
 	return nil
 }
+
+// SupernetCapacity represents how much of the globalnet supernet is allocated to the clusters
+type SupernetCapacity struct {
+	CIDRRange       string
+	TotalIPs        *big.Int
+	AllocatedBlocks int
+	AllocatedIPs    *big.Int
+	FreeIPs         *big.Int
+	// LargestFreeIPs is the size of the largest contiguous range of free IPs
+	LargestFreeIPs *big.Int
+	// Fragmentation is the share of the free IPs outside the largest free range, from 0 to 1
+	Fragmentation float64
+}
+
+type ipRange struct {
+	first, last *big.Int
+}
+
+// GetSupernetCapacity computes the capacity of the globalnet supernet from the global CIDRs of every cluster,
+// global CIDRs outside the supernet are ignored
+func GetSupernetCapacity(globalnetInfo *GlobalnetInfo) (*SupernetCapacity, error) {
+	_, supernet, err := net.ParseCIDR(globalnetInfo.GlobalnetCidrRange)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GlobalCIDR %s configured", globalnetInfo.GlobalnetCidrRange)
+	}
+	supernetRange := ipRange{first: ipToInt(supernet.IP), last: LastIP(supernet)}
+	capacity := &SupernetCapacity{
+		CIDRRange:      supernet.String(),
+		TotalIPs:       rangeSize(supernetRange),
+		AllocatedIPs:   new(big.Int),
+		FreeIPs:        new(big.Int),
+		LargestFreeIPs: new(big.Int),
+	}
+
+	allocated := []ipRange{}
+	for _, globalNetwork := range globalnetInfo.GlobalCidrInfo {
+		for _, cidr := range globalNetwork.GlobalCIDRs {
+			_, network, err := net.ParseCIDR(cidr)
+			if err != nil || !supernet.Contains(network.IP) {
+				continue
+			}
+			allocated = append(allocated, ipRange{first: ipToInt(network.IP), last: LastIP(network)})
+		}
+	}
+	capacity.AllocatedBlocks = len(allocated)
+	sort.Slice(allocated, func(i, j int) bool {
+		return allocated[i].first.Cmp(allocated[j].first) < 0
+	})
+
+	// Walk the allocated blocks in order, the free ranges are the gaps between them
+	next := supernetRange.first
+	addFree := func(free ipRange) {
+		if free.first.Cmp(free.last) > 0 {
+			return
+		}
+		size := rangeSize(free)
+		capacity.FreeIPs.Add(capacity.FreeIPs, size)
+		if size.Cmp(capacity.LargestFreeIPs) > 0 {
+			capacity.LargestFreeIPs = size
+		}
+	}
+	for _, block := range allocated {
+		if block.last.Cmp(next) < 0 {
+			// Overlaps a block already accounted for
+			continue
+		}
+		first := block.first
+		if first.Cmp(next) < 0 {
+			first = next
+		}
+		addFree(ipRange{first: next, last: new(big.Int).Sub(first, big.NewInt(1))})
+		capacity.AllocatedIPs.Add(capacity.AllocatedIPs, rangeSize(ipRange{first: first, last: block.last}))
+		next = new(big.Int).Add(block.last, big.NewInt(1))
+	}
+	addFree(ipRange{first: next, last: supernetRange.last})
+
+	if capacity.FreeIPs.Sign() > 0 {
+		largest, _ := new(big.Float).SetInt(capacity.LargestFreeIPs).Float64()
+		free, _ := new(big.Float).SetInt(capacity.FreeIPs).Float64()
+		capacity.Fragmentation = 1 - largest/free
+	}
+	return capacity, nil
+}
+
+func rangeSize(r ipRange) *big.Int {
+	size := new(big.Int).Sub(r.last, r.first)
+	return size.Add(size, big.NewInt(1))
+}
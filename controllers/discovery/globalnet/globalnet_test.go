@@ -17,8 +17,19 @@ limitations under the License.
 package globalnet
 
 import (
+	"sync"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	submarinerv1 "github.com/submariner-io/submariner/pkg/apis/submariner.io/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	operatorv1alpha1 "github.com/DanielXLee/cluster-fabric-operator/api/v1alpha1"
+	"github.com/DanielXLee/cluster-fabric-operator/controllers/ensures/broker"
 )
 
 var _ = Describe("IsOverlappingCidr", func() {
@@ -213,3 +224,308 @@ var _ = Describe("AllocateGlobalCIDR: Fail", func() {
 		})
 	})
 })
+
+var _ = Describe("Globalnet allocation from the join config", func() {
+	var globalnetInfo *GlobalnetInfo
+
+	BeforeEach(func() {
+		globalnetInfo = &GlobalnetInfo{
+			GlobalnetEnabled:     true,
+			GlobalnetCidrRange:   "169.254.0.0/16",
+			GlobalnetClusterSize: 8192,
+			GlobalCidrInfo: map[string]*GlobalNetwork{
+				"cluster1": {ClusterID: "cluster1", GlobalCIDRs: []string{"169.254.0.0/19"}},
+			},
+		}
+	})
+
+	allocate := func(netconfig Config) ([]string, error) {
+		cidr, err := ValidateGlobalnetConfiguration(globalnetInfo, netconfig)
+		if err != nil {
+			return nil, err
+		}
+		netconfig.GlobalnetCIDR = cidr
+		return AssignGlobalnetIPs(globalnetInfo, netconfig)
+	}
+
+	When("Neither a CIDR nor a cluster size is requested", func() {
+		It("Should allocate a block of the broker default size", func() {
+			Expect(allocate(Config{ClusterID: "cluster2"})).To(Equal([]string{"169.254.32.0/19"}))
+		})
+
+		It("Should keep the block already allocated to the cluster", func() {
+			Expect(allocate(Config{ClusterID: "cluster1"})).To(Equal([]string{"169.254.0.0/19"}))
+		})
+	})
+
+	When("A cluster size is requested", func() {
+		It("Should allocate a block of that size", func() {
+			Expect(allocate(Config{ClusterID: "cluster2", GlobalnetClusterSize: 1024})).To(Equal([]string{"169.254.32.0/22"}))
+		})
+
+		It("Should round the size up to a power of 2", func() {
+			Expect(allocate(Config{ClusterID: "cluster2", GlobalnetClusterSize: 1000})).To(Equal([]string{"169.254.32.0/22"}))
+		})
+
+		It("Should refuse a size larger than half the supernet", func() {
+			_, err := allocate(Config{ClusterID: "cluster2", GlobalnetClusterSize: 65536})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	When("A larger cluster size is requested by a cluster with global CIDRs", func() {
+		It("Should append a block for the missing IPs", func() {
+			Expect(allocate(Config{ClusterID: "cluster1", GlobalnetClusterSize: 16384})).To(Equal(
+				[]string{"169.254.0.0/19", "169.254.32.0/19"}))
+		})
+
+		It("Should keep the global CIDRs when they are large enough", func() {
+			Expect(allocate(Config{ClusterID: "cluster1", GlobalnetClusterSize: 4096})).To(Equal([]string{"169.254.0.0/19"}))
+		})
+
+		It("Should not overlap the blocks of the other clusters", func() {
+			globalnetInfo.GlobalCidrInfo["cluster2"] = &GlobalNetwork{ClusterID: "cluster2", GlobalCIDRs: []string{"169.254.32.0/19"}}
+			Expect(allocate(Config{ClusterID: "cluster1", GlobalnetClusterSize: 16384})).To(Equal(
+				[]string{"169.254.0.0/19", "169.254.64.0/19"}))
+		})
+	})
+
+	When("A CIDR is requested", func() {
+		It("Should use that CIDR", func() {
+			Expect(allocate(Config{ClusterID: "cluster2", GlobalnetCIDR: "169.254.64.0/20"})).To(Equal([]string{"169.254.64.0/20"}))
+		})
+
+		It("Should refuse a CIDR overlapping another cluster", func() {
+			_, err := allocate(Config{ClusterID: "cluster2", GlobalnetCIDR: "169.254.16.0/20"})
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should refuse a CIDR outside the supernet", func() {
+			_, err := allocate(Config{ClusterID: "cluster2", GlobalnetCIDR: "10.0.0.0/20"})
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should refuse a cluster size along with it", func() {
+			_, err := allocate(Config{ClusterID: "cluster2", GlobalnetCIDR: "169.254.64.0/20", GlobalnetClusterSize: 1024})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
+
+var _ = Describe("Allocator", func() {
+	var allocator *Allocator
+
+	BeforeEach(func() {
+		var err error
+		allocator, err = NewAllocator("169.254.0.0/16")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(allocator.Reserve("169.254.0.0/19")).To(Succeed())
+	})
+
+	When("Allocating concurrently", func() {
+		It("Should allocate distinct CIDRs until the supernet is full", func() {
+			var wg sync.WaitGroup
+			results := make(chan string, 8)
+			for i := 0; i < 8; i++ {
+				wg.Add(1)
+				go func() {
+					defer GinkgoRecover()
+					defer wg.Done()
+					if cidr, err := allocator.Allocate(8192); err == nil {
+						results <- cidr
+					}
+				}()
+			}
+			wg.Wait()
+			close(results)
+
+			allocated := map[string]bool{}
+			for cidr := range results {
+				Expect(allocated).NotTo(HaveKey(cidr))
+				allocated[cidr] = true
+			}
+			Expect(allocated).To(HaveLen(7))
+			Expect(allocated).NotTo(HaveKey("169.254.0.0/19"))
+		})
+	})
+
+	When("Allocating a requested CIDR", func() {
+		It("Should refuse it once allocated", func() {
+			Expect(allocator.AllocateCIDR("169.254.32.0/20")).To(Succeed())
+			Expect(allocator.AllocateCIDR("169.254.32.0/24")).NotTo(Succeed())
+			Expect(allocator.AllocateCIDR("169.254.0.0/24")).NotTo(Succeed())
+			Expect(allocator.Allocate(4096)).To(Equal("169.254.48.0/20"))
+		})
+	})
+})
+
+var _ = Describe("IPv6 Allocator", func() {
+	var allocator *Allocator
+
+	BeforeEach(func() {
+		var err error
+		allocator, err = NewAllocator("fd00:242::/48")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(allocator.Reserve("fd00:242::/64", "169.254.0.0/19")).To(Succeed())
+	})
+
+	When("Allocating by cluster size", func() {
+		It("Should allocate the next free block past the reserved ones", func() {
+			Expect(allocator.Allocate(1<<64 - 1)).To(Equal("fd00:242:0:1::/64"))
+			Expect(allocator.Allocate(1 << 32)).To(Equal("fd00:242:0:2::/96"))
+		})
+
+		It("Should fail once the supernet is full", func() {
+			allocator, err := NewAllocator("fd00:242::/126")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(allocator.Allocate(2)).To(Equal("fd00:242::/127"))
+			Expect(allocator.Allocate(2)).To(Equal("fd00:242::2/127"))
+			_, err = allocator.Allocate(2)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	When("Allocating a requested CIDR", func() {
+		It("Should refuse one outside the supernet", func() {
+			Expect(allocator.AllocateCIDR("fd00:243::/64")).NotTo(Succeed())
+			Expect(allocator.AllocateCIDR("fd00:242:0:ff::/64")).To(Succeed())
+		})
+	})
+
+	When("Validating the cluster size", func() {
+		It("Should accept sizes beyond 32 bits", func() {
+			Expect(GetValidClusterSize("fd00:242::/48", 1<<40+1)).To(Equal(uint(1 << 41)))
+		})
+	})
+
+	When("Assigning the global CIDRs of a joining cluster", func() {
+		It("Should allocate them from the IPv6 supernet", func() {
+			globalnetInfo := &GlobalnetInfo{
+				GlobalnetEnabled:     true,
+				GlobalnetCidrRange:   "fd00:242::/48",
+				GlobalnetClusterSize: 1<<64 - 1,
+				GlobalCidrInfo: map[string]*GlobalNetwork{
+					"cluster1": {ClusterID: "cluster1", GlobalCIDRs: []string{"fd00:242::/64"}},
+				},
+			}
+			Expect(AssignGlobalnetIPs(globalnetInfo, Config{ClusterID: "cluster2"})).To(Equal([]string{"fd00:242:0:1::/64"}))
+		})
+	})
+})
+
+var _ = Describe("Supernet capacity", func() {
+	It("Should account the allocated blocks and the free ranges between them", func() {
+		globalnetInfo := &GlobalnetInfo{
+			GlobalnetCidrRange: "169.254.0.0/16",
+			GlobalCidrInfo: map[string]*GlobalNetwork{
+				"cluster1": {ClusterID: "cluster1", GlobalCIDRs: []string{"169.254.0.0/19", "169.254.64.0/19"}},
+				"cluster2": {ClusterID: "cluster2", GlobalCIDRs: []string{"169.254.128.0/18"}},
+				"cluster3": {ClusterID: "cluster3", GlobalCIDRs: []string{"10.0.0.0/19"}},
+			},
+		}
+		capacity, err := GetSupernetCapacity(globalnetInfo)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(capacity.TotalIPs.Int64()).To(Equal(int64(65536)))
+		Expect(capacity.AllocatedBlocks).To(Equal(3))
+		Expect(capacity.AllocatedIPs.Int64()).To(Equal(int64(32768)))
+		Expect(capacity.FreeIPs.Int64()).To(Equal(int64(32768)))
+		Expect(capacity.LargestFreeIPs.Int64()).To(Equal(int64(16384)))
+		Expect(capacity.Fragmentation).To(BeNumerically("~", 0.5))
+	})
+
+	It("Should report an empty supernet as unfragmented", func() {
+		capacity, err := GetSupernetCapacity(&GlobalnetInfo{GlobalnetCidrRange: "fd00:242::/48"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(capacity.AllocatedBlocks).To(BeZero())
+		Expect(capacity.FreeIPs).To(Equal(capacity.TotalIPs))
+		Expect(capacity.Fragmentation).To(BeZero())
+	})
+})
+
+var _ = Describe("Overlapping clusters", func() {
+	var scheme *runtime.Scheme
+
+	BeforeEach(func() {
+		// The broker cluster is read with the scheme the joining clusters use
+		scheme = broker.NewBrokerAdministratorScheme()
+	})
+
+	newCluster := func(clusterID, podCIDR, serviceCIDR string) *submarinerv1.Cluster {
+		return &submarinerv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: clusterID, Namespace: "submariner-k8s-broker"},
+			Spec: submarinerv1.ClusterSpec{
+				ClusterID:   clusterID,
+				ClusterCIDR: []string{podCIDR},
+				ServiceCIDR: []string{serviceCIDR},
+			},
+		}
+	}
+
+	It("Should report the CIDRs overlapping another cluster", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			newCluster("cluster1", "10.244.0.0/16", "10.96.0.0/12"),
+			newCluster("cluster2", "10.245.0.0/16", "10.112.0.0/12"),
+		).Build()
+		overlaps, err := FindOverlappingClusters(c, "submariner-k8s-broker", Config{
+			ClusterID:   "cluster3",
+			ClusterCIDR: "10.244.128.0/17,fd00:10:244::/56",
+			ServiceCIDR: "10.128.0.0/12",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(overlaps).To(HaveLen(1))
+		Expect(overlaps[0]).To(ContainSubstring("cluster1"))
+	})
+
+	It("Should ignore the Cluster object of the cluster itself", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			newCluster("cluster1", "10.244.0.0/16", "10.96.0.0/12"),
+		).Build()
+		overlaps, err := FindOverlappingClusters(c, "submariner-k8s-broker", Config{
+			ClusterID:   "cluster1",
+			ClusterCIDR: "10.244.0.0/16",
+			ServiceCIDR: "10.96.0.0/12",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(overlaps).To(BeEmpty())
+	})
+})
+
+var _ = Describe("Enabling globalnet on an existing broker", func() {
+	var scheme *runtime.Scheme
+
+	BeforeEach(func() {
+		scheme = runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(submarinerv1.AddToScheme(scheme)).To(Succeed())
+		Expect(operatorv1alpha1.AddToScheme(scheme)).To(Succeed())
+	})
+
+	newClusterSA := func(clusterID string) *v1.ServiceAccount {
+		return &v1.ServiceAccount{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-" + clusterID, Namespace: "submariner-k8s-broker"},
+		}
+	}
+
+	It("Should allocate distinct global CIDRs to the joined clusters without one", func() {
+		cm := &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: broker.GlobalCIDRConfigMapName, Namespace: "submariner-k8s-broker"},
+		}
+		Expect(broker.GeneralGlobalnetConfigMap(cm, true, "242.0.0.0/16", 8192)).To(Succeed())
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			cm, newClusterSA("cluster1"), newClusterSA("cluster2"), newClusterSA("cluster3"),
+		).Build()
+		Expect(broker.RecordGlobalCIDRs(c, c, "submariner-k8s-broker", cm,
+			broker.ClusterInfo{ClusterID: "cluster1", GlobalCidr: []string{"242.0.0.0/19"}}, nil)).To(Succeed())
+
+		Expect(AllocateJoinedClusters(c, c, "submariner-k8s-broker")).To(Succeed())
+
+		globalnetInfo, _, err := GetGlobalNetworks(c, "submariner-k8s-broker")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(globalnetInfo.GlobalCidrInfo).To(HaveLen(3))
+		Expect(globalnetInfo.GlobalCidrInfo["cluster1"].GlobalCIDRs).To(Equal([]string{"242.0.0.0/19"}))
+		Expect(globalnetInfo.GlobalCidrInfo["cluster2"].GlobalCIDRs).To(HaveLen(1))
+		Expect(globalnetInfo.GlobalCidrInfo["cluster3"].GlobalCIDRs).To(HaveLen(1))
+		Expect(globalnetInfo.GlobalCidrInfo["cluster2"].GlobalCIDRs[0]).NotTo(
+			BeElementOf("242.0.0.0/19", globalnetInfo.GlobalCidrInfo["cluster3"].GlobalCIDRs[0]))
+	})
+})
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"os"
 	"regexp"
+	"strings"
 
 	"github.com/pkg/errors"
 	v1 "k8s.io/api/core/v1"
@@ -54,7 +55,8 @@ func discoverNetwork(c client.Client) (*ClusterNetwork, error) {
 	}
 
 	if podIPRange != "" {
-		clusterNetwork.PodCIDRs = []string{podIPRange}
+		// Dual-stack clusters list a CIDR of each family
+		clusterNetwork.PodCIDRs = strings.Split(podIPRange, ",")
 	}
 
 	clusterIPRange, err := findClusterIPRange(c)
@@ -63,7 +65,7 @@ func discoverNetwork(c client.Client) (*ClusterNetwork, error) {
 	}
 
 	if clusterIPRange != "" {
-		clusterNetwork.ServiceCIDRs = []string{clusterIPRange}
+		clusterNetwork.ServiceCIDRs = strings.Split(clusterIPRange, ",")
 	}
 
 	if len(clusterNetwork.PodCIDRs) > 0 || len(clusterNetwork.ServiceCIDRs) > 0 {
@@ -186,6 +188,10 @@ func findPodIPRangeFromNodeSpec(c client.Client) (string, error) {
 
 func parseToPodCidr(nodes []v1.Node) (string, error) {
 	for _, node := range nodes {
+		// PodCIDRs holds the CIDR of each family on dual-stack clusters, PodCIDR only the first one
+		if len(node.Spec.PodCIDRs) > 0 {
+			return strings.Join(node.Spec.PodCIDRs, ","), nil
+		}
 		if node.Spec.PodCIDR != "" {
 			return node.Spec.PodCIDR, nil
 		}
@@ -18,10 +18,10 @@ package network
 
 import (
 	"context"
-	"fmt"
 
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/dynamic"
+	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	submariner "github.com/submariner-io/submariner-operator/apis/submariner/v1alpha1"
@@ -37,27 +37,15 @@ type ClusterNetwork struct {
 	PluginSettings map[string]string
 }
 
-func (cn *ClusterNetwork) Show() {
+func (cn *ClusterNetwork) Log() {
 	if cn == nil {
-		fmt.Println("    No network details discovered")
-	} else {
-		fmt.Printf("    Discovered network details:\n")
-		fmt.Printf("        Network plugin:  %s\n", cn.NetworkPlugin)
-		fmt.Printf("        Service CIDRs:   %v\n", cn.ServiceCIDRs)
-		fmt.Printf("        Cluster CIDRs:   %v\n", cn.PodCIDRs)
-		if cn.GlobalCIDR != "" {
-			fmt.Printf("        Global CIDR:     %v\n", cn.GlobalCIDR)
-		}
+		klog.Info("No network details discovered")
+		return
 	}
+	klog.Infof("Discovered network details: plugin %s, service CIDRs %v, cluster CIDRs %v, global CIDR %q",
+		cn.NetworkPlugin, cn.ServiceCIDRs, cn.PodCIDRs, cn.GlobalCIDR)
 }
 
-// func (cn *ClusterNetwork) Log(logger logr.Logger) {
-// 	logger.Info("Discovered K8s network details",
-// 		"plugin", cn.NetworkPlugin,
-// 		"clusterCIDRs", cn.PodCIDRs,
-// 		"serviceCIDRs", cn.ServiceCIDRs)
-// }
-
 func (cn *ClusterNetwork) IsComplete() bool {
 	return cn != nil && len(cn.ServiceCIDRs) > 0 && len(cn.PodCIDRs) > 0
 }
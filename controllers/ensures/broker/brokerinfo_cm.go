@@ -17,12 +17,16 @@ limitations under the License.
 package broker
 
 import (
+	"bytes"
 	"context"
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/json"
+	"fmt"
 
+	submarinerv1 "github.com/submariner-io/submariner/pkg/apis/submariner.io/v1"
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -32,6 +36,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	operatorv1alpha1 "github.com/DanielXLee/cluster-fabric-operator/api/v1alpha1"
+	operatorv1alpha2 "github.com/DanielXLee/cluster-fabric-operator/api/v1alpha2"
 	"github.com/DanielXLee/cluster-fabric-operator/controllers/stringset"
 
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
@@ -52,9 +57,16 @@ type BrokerInfo struct {
 	DefaultGlobalnetClusterSize uint       `json:"defaultGlobalnetClusterSize,omitempty"`
 }
 
-const ipsecPSKSecretName = "submariner-ipsec-psk"
+// IPSecPSKSecretName is the secret of the broker namespace persisting the IPsec PSK
+const IPSecPSKSecretName = "submariner-ipsec-psk"
 const ipsecSecretLength = 48
 
+// BrokerInfoKey is the key of the broker info in the broker info configmap and secret
+const BrokerInfoKey = "brokerInfo"
+
+// IPSecPSKRotationAnnotation records the rotation trigger the persisted IPsec PSK was generated for
+const IPSecPSKRotationAnnotation = "operator.tkestack.io/ipsec-psk-rotation"
+
 func (data *BrokerInfo) SetComponents(componentSet stringset.Interface) {
 	data.Components = componentSet.Elements()
 }
@@ -75,6 +87,19 @@ func (data *BrokerInfo) IsGlobalnetEnabled() bool {
 	return data.GetComponents().Contains(components.Globalnet)
 }
 
+// HasCredentials returns whether the broker info carries the broker credentials and the IPsec PSK
+func (data *BrokerInfo) HasCredentials() bool {
+	return data.ClientToken != nil || data.IPSecPSK != nil
+}
+
+// WithoutCredentials returns a copy of the broker info without the broker credentials and the IPsec PSK
+func (data *BrokerInfo) WithoutCredentials() *BrokerInfo {
+	metadata := *data
+	metadata.ClientToken = nil
+	metadata.IPSecPSK = nil
+	return &metadata
+}
+
 func (data *BrokerInfo) ToString() (string, error) {
 	jsonBytes, err := json.Marshal(data)
 	if err != nil {
@@ -92,7 +117,9 @@ func NewFromString(str string) (*BrokerInfo, error) {
 	return data, json.Unmarshal(bytes, data)
 }
 
-func (data *BrokerInfo) WriteConfigMap(c client.Client, instance *operatorv1alpha1.Fabric) error {
+// WriteConfigMap publishes the non-sensitive broker info as a configmap, and the complete broker info,
+// including the broker credentials and the IPsec PSK, as a secret
+func (data *BrokerInfo) WriteConfigMap(c client.Client, instance *operatorv1alpha2.Fabric) error {
 	cm := &v1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      consts.SubmarinerBrokerInfo,
@@ -104,18 +131,48 @@ func (data *BrokerInfo) WriteConfigMap(c client.Client, instance *operatorv1alph
 	labels[consts.FabricNamespaceLabel] = instance.GetNamespace()
 
 	or, err := ctrl.CreateOrUpdate(context.TODO(), c, cm, func() error {
-		dataStr, err := data.ToString()
+		dataStr, err := data.WithoutCredentials().ToString()
 		if err != nil {
 			return err
 		}
 		cm.ObjectMeta.Labels = labels
-		cm.Data = map[string]string{"brokerInfo": dataStr}
+		cm.Data = map[string]string{BrokerInfoKey: dataStr}
 		return nil
 	})
 	if err != nil {
 		return err
 	}
 	klog.Infof("Configmap %s %s", consts.SubmarinerBrokerInfo, or)
+	return data.WriteSecret(c, instance)
+}
+
+// WriteSecret publishes the broker info as a secret in the broker namespace, the secret can be
+// exported to the managed clusters, or read from the broker cluster by the joining clusters
+func (data *BrokerInfo) WriteSecret(c client.Client, instance *operatorv1alpha2.Fabric) error {
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      consts.SubmarinerBrokerInfo,
+			Namespace: consts.SubmarinerBrokerNamespace,
+		},
+	}
+	labels := make(map[string]string)
+	labels[consts.FabricNameLabel] = instance.GetName()
+	labels[consts.FabricNamespaceLabel] = instance.GetNamespace()
+
+	or, err := ctrl.CreateOrUpdate(context.TODO(), c, secret, func() error {
+		dataStr, err := data.ToString()
+		if err != nil {
+			return err
+		}
+		secret.ObjectMeta.Labels = labels
+		secret.Type = v1.SecretTypeOpaque
+		secret.Data = map[string][]byte{BrokerInfoKey: []byte(dataStr)}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	klog.Infof("Secret %s %s", consts.SubmarinerBrokerInfo, or)
 	return nil
 }
 
@@ -125,33 +182,97 @@ func NewFromConfigMap(c client.Client) (*BrokerInfo, error) {
 	if err := c.Get(context.TODO(), cmKey, cm); err != nil {
 		return nil, err
 	}
-	return NewFromString(cm.Data["brokerInfo"])
+	data, err := NewFromString(cm.Data[BrokerInfoKey])
+	if err != nil {
+		return nil, err
+	}
+	if data.HasCredentials() {
+		// Written by an operator version which kept the credentials in the configmap
+		return data, nil
+	}
+	secretKey := types.NamespacedName{Name: consts.SubmarinerBrokerInfo, Namespace: consts.SubmarinerBrokerNamespace}
+	brokerInfo, err := NewFromSecret(c, secretKey)
+	if apierrors.IsNotFound(err) {
+		// The clusters which copied the configmap alone, as needed by the former operator versions, have to copy
+		// the secret too. The error stays a not found one, the callers skip what needs the broker info then.
+		notFound := apierrors.NewNotFound(v1.Resource("secrets"), secretKey.String())
+		notFound.ErrStatus.Message = fmt.Sprintf("the broker info configmap %s carries no credentials, "+
+			"and the broker info secret %s holding them is not found, copy it from the broker cluster", cmKey, secretKey)
+		return nil, notFound
+	}
+	return brokerInfo, err
+}
+
+// MigrateBrokerInfoConfigMap moves the credentials out of a broker info configmap written by
+// a previous operator version, into the broker info secret
+func MigrateBrokerInfoConfigMap(c client.Client, instance *operatorv1alpha2.Fabric) error {
+	cm := &v1.ConfigMap{}
+	cmKey := types.NamespacedName{Name: consts.SubmarinerBrokerInfo, Namespace: consts.SubmarinerBrokerNamespace}
+	if err := c.Get(context.TODO(), cmKey, cm); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	data, err := NewFromString(cm.Data[BrokerInfoKey])
+	if err != nil {
+		return err
+	}
+	if !data.HasCredentials() {
+		return nil
+	}
+	klog.Infof("Migrating the broker credentials from configmap %s to a secret", consts.SubmarinerBrokerInfo)
+	return data.WriteConfigMap(c, instance)
+}
+
+// NewFromSecret reads the broker info from the given secret
+func NewFromSecret(reader client.Reader, secretKey types.NamespacedName) (*BrokerInfo, error) {
+	secret := &v1.Secret{}
+	if err := reader.Get(context.TODO(), secretKey, secret); err != nil {
+		return nil, err
+	}
+	dataStr, ok := secret.Data[BrokerInfoKey]
+	if !ok {
+		return nil, fmt.Errorf("secret %s does not have the %s key", secretKey, BrokerInfoKey)
+	}
+	return NewFromString(string(dataStr))
 }
 
-func NewFromCluster(c client.Client, restConfig *rest.Config) (*BrokerInfo, error) {
+func NewFromCluster(c client.Client, restConfig *rest.Config, ipsecPSKRotation string) (*BrokerInfo, error) {
 	brokerInfo := &BrokerInfo{}
 	var err error
 	brokerInfo.ClientToken, err = GetClientTokenSecret(c, consts.SubmarinerBrokerNamespace, SubmarinerBrokerAdminSA)
 	if err != nil {
 		return nil, err
 	}
-	brokerInfo.IPSecPSK, err = newIPSECPSKSecret()
+	pskSecret, err := EnsureIPSecPSKSecret(c, ipsecPSKRotation)
 	if err != nil {
 		return nil, err
 	}
+	brokerInfo.IPSecPSK = &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: IPSecPSKSecretName,
+		},
+		Data: pskSecret.Data,
+	}
 	brokerInfo.BrokerURL = restConfig.Host + restConfig.APIPath
 	return brokerInfo, err
 }
 
-func CreateBrokerInfoConfigMap(c client.Client, restConfig *rest.Config, instance *operatorv1alpha1.Fabric) error {
+func CreateBrokerInfoConfigMap(c client.Client, restConfig *rest.Config, instance *operatorv1alpha2.Fabric) error {
+	if err := MigrateBrokerInfoConfigMap(c, instance); err != nil {
+		return err
+	}
+
 	klog.Info("Create or update broker info configmap")
-	brokerInfo, err := NewFromCluster(c, restConfig)
+	brokerConfig := instance.Spec.GetBrokerConfig()
+	brokerInfo, err := NewFromCluster(c, restConfig, brokerConfig.IPSecPSKRotation)
 	if err != nil {
 		return err
 	}
-	brokerConfig := instance.Spec.BrokerConfig
 	brokerInfo.GlobalnetCIDRRange = brokerConfig.GlobalnetCIDRRange
 	brokerInfo.DefaultGlobalnetClusterSize = brokerConfig.DefaultGlobalnetClusterSize
+	// The joining clusters deploy the components the broker publishes here: service discovery
+	// unless the broker turns it off, globalnet only when the broker enables it. The joined
+	// clusters pick up a change on their next resync.
+	brokerInfo.SetComponents(brokerComponents(brokerConfig))
 
 	if len(brokerConfig.DefaultCustomDomains) > 0 {
 		brokerInfo.CustomDomains = &brokerConfig.DefaultCustomDomains
@@ -163,15 +284,35 @@ func CreateBrokerInfoConfigMap(c client.Client, restConfig *rest.Config, instanc
 	return nil
 }
 
+// brokerComponents returns the Submariner components the clusters joining the broker deploy
+func brokerComponents(brokerConfig *operatorv1alpha2.BrokerConfig) stringset.Interface {
+	componentSet := stringset.New(components.Connectivity)
+	if operatorv1alpha2.IsEnabled(brokerConfig.ServiceDiscoveryEnabled) {
+		componentSet.Add(components.ServiceDiscovery)
+	}
+	if brokerConfig.GlobalnetEnable {
+		componentSet.Add(components.Globalnet)
+	}
+	return componentSet
+}
+
 func (data *BrokerInfo) GetBrokerAdministratorCluster() (cluster.Cluster, error) {
 	config := data.GetBrokerAdministratorConfig()
-	scheme := runtime.NewScheme()
-	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 	return cluster.New(config, func(clusterOptions *cluster.Options) {
-		clusterOptions.Scheme = scheme
+		clusterOptions.Scheme = NewBrokerAdministratorScheme()
 	})
 }
 
+// NewBrokerAdministratorScheme returns the scheme of the objects the joining clusters read and write on the broker:
+// the service accounts and configmaps, the global CIDR allocations, and the Cluster objects of Submariner
+func NewBrokerAdministratorScheme() *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(operatorv1alpha1.AddToScheme(scheme))
+	utilruntime.Must(submarinerv1.AddToScheme(scheme))
+	return scheme
+}
+
 func (data *BrokerInfo) GetBrokerAdministratorConfig() *rest.Config {
 	tlsClientConfig := rest.TLSClientConfig{}
 	if len(data.ClientToken.Data["ca.crt"]) != 0 {
@@ -193,6 +334,58 @@ func generateRandomPSK(n int) ([]byte, error) {
 	return psk, err
 }
 
+// EnsureIPSecPSKSecret returns the IPsec PSK persisted in the broker namespace. A new PSK is only generated
+// when there is none yet, or when the rotation trigger differs from the one the current PSK was generated for.
+func EnsureIPSecPSKSecret(c client.Client, rotation string) (*v1.Secret, error) {
+	pskSecret := &v1.Secret{}
+	pskKey := types.NamespacedName{Name: IPSecPSKSecretName, Namespace: consts.SubmarinerBrokerNamespace}
+	err := c.Get(context.TODO(), pskKey, pskSecret)
+	if err == nil {
+		if pskSecret.Annotations[IPSecPSKRotationAnnotation] == rotation && len(pskSecret.Data["psk"]) > 0 {
+			return pskSecret, nil
+		}
+		klog.Infof("Rotating the IPsec PSK, rotation %q", rotation)
+		newSecret, err := newIPSECPSKSecret()
+		if err != nil {
+			return nil, err
+		}
+		if pskSecret.Annotations == nil {
+			pskSecret.Annotations = map[string]string{}
+		}
+		pskSecret.Annotations[IPSecPSKRotationAnnotation] = rotation
+		pskSecret.Data = newSecret.Data
+		return pskSecret, c.Update(context.TODO(), pskSecret)
+	}
+	if !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+
+	// Keep the PSK already handed out to the joined clusters by an operator version
+	// which did not persist it on its own
+	pskSecret, err = newIPSECPSKSecret()
+	if err != nil {
+		return nil, err
+	}
+	if existing, err := NewFromConfigMap(c); err == nil && existing.IPSecPSK != nil && len(existing.IPSecPSK.Data["psk"]) > 0 {
+		klog.Info("Persisting the IPsec PSK of the existing broker info")
+		pskSecret.Data = existing.IPSecPSK.Data
+	}
+	pskSecret.Namespace = consts.SubmarinerBrokerNamespace
+	pskSecret.Annotations = map[string]string{IPSecPSKRotationAnnotation: rotation}
+	return pskSecret, c.Create(context.TODO(), pskSecret)
+}
+
+// HasCurrentIPSecPSK tells whether the IPsec PSK of the broker info is the one persisted in the broker namespace,
+// read through the broker cluster reader. A copy of the broker info holds the former PSK once the broker rotated it.
+func (data *BrokerInfo) HasCurrentIPSecPSK(reader client.Reader, brokerNamespace string) (bool, error) {
+	pskSecret := &v1.Secret{}
+	pskKey := types.NamespacedName{Name: IPSecPSKSecretName, Namespace: brokerNamespace}
+	if err := reader.Get(context.TODO(), pskKey, pskSecret); err != nil {
+		return false, err
+	}
+	return data.IPSecPSK != nil && bytes.Equal(data.IPSecPSK.Data["psk"], pskSecret.Data["psk"]), nil
+}
+
 func newIPSECPSKSecret() (*v1.Secret, error) {
 	psk, err := generateRandomPSK(ipsecSecretLength)
 	if err != nil {
@@ -204,7 +397,7 @@ func newIPSECPSKSecret() (*v1.Secret, error) {
 
 	pskSecret := &v1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: ipsecPSKSecretName,
+			Name: IPSecPSKSecretName,
 		},
 		Data: pskSecretData,
 	}
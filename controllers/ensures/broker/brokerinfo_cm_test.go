@@ -17,10 +17,19 @@ limitations under the License.
 package broker
 
 import (
+	"context"
 	"encoding/base64"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	operatorv1alpha2 "github.com/DanielXLee/cluster-fabric-operator/api/v1alpha2"
+	"github.com/DanielXLee/cluster-fabric-operator/controllers/components"
 )
 
 const (
@@ -29,6 +38,8 @@ const (
 	BrokerSA                  = "submariner-k8s-broker-admin"
 )
 
+var brokerInfoKey = types.NamespacedName{Name: "submariner-broker-info", Namespace: SubmarinerBrokerNamespace}
+
 var _ = Describe("datafile", func() {
 	When("Doing basic encoding to string", func() {
 		It("Should generate data", func() {
@@ -62,6 +73,165 @@ var _ = Describe("datafile", func() {
 		})
 	})
 
+	When("Reading data from a secret", func() {
+		secretKey := types.NamespacedName{Name: "broker-info", Namespace: "default"}
+
+		It("Should recover the data", func() {
+			data := &BrokerInfo{BrokerURL: testBrokerURL}
+			str, _ := data.ToString()
+			c := fake.NewClientBuilder().WithObjects(&v1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: secretKey.Name, Namespace: secretKey.Namespace},
+				Data:       map[string][]byte{BrokerInfoKey: []byte(str)},
+			}).Build()
+			newData, err := NewFromSecret(c, secretKey)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(newData.BrokerURL).To(Equal(data.BrokerURL))
+		})
+
+		It("Should fail when the broker info key is missing", func() {
+			c := fake.NewClientBuilder().WithObjects(&v1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: secretKey.Name, Namespace: secretKey.Namespace},
+			}).Build()
+			_, err := NewFromSecret(c, secretKey)
+			Expect(err).Should(HaveOccurred())
+		})
+	})
+
+	When("Writing the broker info", func() {
+		var data *BrokerInfo
+		var instance *operatorv1alpha2.Fabric
+
+		BeforeEach(func() {
+			pskSecret, err := newIPSECPSKSecret()
+			Expect(err).NotTo(HaveOccurred())
+			data = &BrokerInfo{
+				BrokerURL:   testBrokerURL,
+				ClientToken: &v1.Secret{Data: map[string][]byte{"token": []byte("admin-token")}},
+				IPSecPSK:    pskSecret,
+			}
+			instance = &operatorv1alpha2.Fabric{ObjectMeta: metav1.ObjectMeta{Name: "broker", Namespace: "default"}}
+		})
+
+		It("Should keep the credentials out of the configmap", func() {
+			c := fake.NewClientBuilder().Build()
+			Expect(data.WriteConfigMap(c, instance)).To(Succeed())
+
+			cm := &v1.ConfigMap{}
+			Expect(c.Get(context.TODO(), brokerInfoKey, cm)).To(Succeed())
+			cmData, err := NewFromString(cm.Data[BrokerInfoKey])
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cmData.HasCredentials()).To(BeFalse())
+			Expect(cmData.BrokerURL).To(Equal(testBrokerURL))
+
+			newData, err := NewFromConfigMap(c)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(newData.ClientToken.Data["token"]).To(Equal([]byte("admin-token")))
+			Expect(newData.IPSecPSK.Data["psk"]).To(Equal(data.IPSecPSK.Data["psk"]))
+		})
+
+		It("Should name the broker info secret missing next to a configmap", func() {
+			str, _ := data.WithoutCredentials().ToString()
+			c := fake.NewClientBuilder().WithObjects(&v1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: brokerInfoKey.Name, Namespace: brokerInfoKey.Namespace},
+				Data:       map[string]string{BrokerInfoKey: str},
+			}).Build()
+
+			_, err := NewFromConfigMap(c)
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("broker info secret " + brokerInfoKey.String()))
+		})
+
+		It("Should publish the components the broker enables", func() {
+			brokerConfig := &operatorv1alpha2.BrokerConfig{}
+			Expect(brokerComponents(brokerConfig).Elements()).To(ConsistOf(components.Connectivity, components.ServiceDiscovery))
+
+			disabled := false
+			brokerConfig = &operatorv1alpha2.BrokerConfig{ServiceDiscoveryEnabled: &disabled, GlobalnetEnable: true}
+			data.SetComponents(brokerComponents(brokerConfig))
+			Expect(data.IsConnectivityEnabled()).To(BeTrue())
+			Expect(data.IsServiceDiscoveryEnabled()).To(BeFalse())
+			Expect(data.IsGlobalnetEnabled()).To(BeTrue())
+		})
+
+		It("Should migrate a configmap holding the credentials", func() {
+			str, _ := data.ToString()
+			c := fake.NewClientBuilder().WithObjects(&v1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: brokerInfoKey.Name, Namespace: brokerInfoKey.Namespace},
+				Data:       map[string]string{BrokerInfoKey: str},
+			}).Build()
+			Expect(MigrateBrokerInfoConfigMap(c, instance)).To(Succeed())
+
+			cm := &v1.ConfigMap{}
+			Expect(c.Get(context.TODO(), brokerInfoKey, cm)).To(Succeed())
+			cmData, err := NewFromString(cm.Data[BrokerInfoKey])
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cmData.HasCredentials()).To(BeFalse())
+
+			secretData, err := NewFromSecret(c, brokerInfoKey)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(secretData.IPSecPSK.Data["psk"]).To(Equal(data.IPSecPSK.Data["psk"]))
+		})
+	})
+
+	When("Persisting the IPsec PSK", func() {
+		It("Should keep the PSK across reconciles", func() {
+			c := fake.NewClientBuilder().Build()
+			first, err := EnsureIPSecPSKSecret(c, "")
+			Expect(err).NotTo(HaveOccurred())
+			second, err := EnsureIPSecPSKSecret(c, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(second.Data["psk"]).To(Equal(first.Data["psk"]))
+		})
+
+		It("Should generate a new PSK when the rotation changes", func() {
+			c := fake.NewClientBuilder().Build()
+			first, err := EnsureIPSecPSKSecret(c, "")
+			Expect(err).NotTo(HaveOccurred())
+			rotated, err := EnsureIPSecPSKSecret(c, "2021-06-01")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rotated.Data["psk"]).NotTo(Equal(first.Data["psk"]))
+			Expect(rotated.Annotations[IPSecPSKRotationAnnotation]).To(Equal("2021-06-01"))
+
+			again, err := EnsureIPSecPSKSecret(c, "2021-06-01")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(again.Data["psk"]).To(Equal(rotated.Data["psk"]))
+		})
+
+		It("Should persist the PSK of the existing broker info", func() {
+			pskSecret, _ := newIPSECPSKSecret()
+			data := &BrokerInfo{
+				BrokerURL:   testBrokerURL,
+				ClientToken: &v1.Secret{Data: map[string][]byte{"token": []byte("admin-token")}},
+				IPSecPSK:    pskSecret,
+			}
+			str, _ := data.ToString()
+			c := fake.NewClientBuilder().WithObjects(&v1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: brokerInfoKey.Name, Namespace: brokerInfoKey.Namespace},
+				Data:       map[string]string{BrokerInfoKey: str},
+			}).Build()
+
+			persisted, err := EnsureIPSecPSKSecret(c, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(persisted.Data["psk"]).To(Equal(pskSecret.Data["psk"]))
+		})
+
+		It("Should flag a broker info copy holding a rotated PSK", func() {
+			c := fake.NewClientBuilder().Build()
+			first, err := EnsureIPSecPSKSecret(c, "")
+			Expect(err).NotTo(HaveOccurred())
+			data := &BrokerInfo{BrokerURL: testBrokerURL, IPSecPSK: &v1.Secret{Data: first.Data}}
+			current, err := data.HasCurrentIPSecPSK(c, SubmarinerBrokerNamespace)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(current).To(BeTrue())
+
+			_, err = EnsureIPSecPSKSecret(c, "2021-06-01")
+			Expect(err).NotTo(HaveOccurred())
+			current, err = data.HasCurrentIPSecPSK(c, SubmarinerBrokerNamespace)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(current).To(BeFalse())
+		})
+	})
+
 	// When("Getting data from cluster", func() {
 
 	// 	var clientSet *fake.Clientset
@@ -73,6 +73,11 @@ func Ensure(c client.Client, config *rest.Config, serviceDiscoveryEnabled, globa
 	if err := createBrokerClusterRoleAndDefaultSA(c); err != nil {
 		return err
 	}
+
+	// Create the SA allowed to read the broker credentials, its role, and bind them
+	if err := createBrokerInfoReaderRoleAndSA(c); err != nil {
+		return err
+	}
 	_, err = WaitForClientToken(c, SubmarinerBrokerAdminSA)
 	return err
 }
@@ -120,6 +125,21 @@ func CreateSAForCluster(c client.Client, reader client.Reader, clusterID string)
 	return clientToken, nil
 }
 
+// DeleteSAForCluster removes the SA of the cluster, and its binding to the submariner cluster role
+func DeleteSAForCluster(c client.Client, clusterID string) error {
+	saName := fmt.Sprintf(submarinerBrokerClusterSAFmt, clusterID)
+	err := c.Delete(context.TODO(), NewBrokerRoleBinding(saName, submarinerBrokerClusterRole))
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("error deleting cluster sa role binding: %s", err)
+	}
+
+	err = c.Delete(context.TODO(), NewBrokerSA(saName))
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("error deleting cluster sa: %s", err)
+	}
+	return nil
+}
+
 func createBrokerAdministratorRoleAndSA(c client.Client) error {
 	// Create the SA we need for the managing the broker
 	err := CreateNewBrokerSA(c, SubmarinerBrokerAdminSA)
@@ -143,6 +163,26 @@ func createBrokerAdministratorRoleAndSA(c client.Client) error {
 	return nil
 }
 
+func createBrokerInfoReaderRoleAndSA(c client.Client) error {
+	err := CreateNewBrokerSA(c, SubmarinerBrokerInfoReaderSA)
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		klog.Errorf("error creating the broker info reader service account: %v", err)
+		return err
+	}
+
+	if err = CreateOrUpdateBrokerInfoReaderRole(c); err != nil {
+		klog.Errorf("error creating broker info reader role: %v", err)
+		return err
+	}
+
+	err = CreateNewBrokerRoleBinding(c, SubmarinerBrokerInfoReaderSA, SubmarinerBrokerInfoReaderRole)
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		klog.Errorf("error creating the broker info reader rolebinding: %v", err)
+		return err
+	}
+	return nil
+}
+
 func WaitForClientToken(reader client.Reader, submarinerBrokerSA string) (secret *v1.Secret, err error) {
 	// wait for the client token to be ready, while implementing
 	// exponential backoff pattern, it will wait a total of:
@@ -174,6 +214,14 @@ func CreateNewBrokerNamespace(c client.Client) error {
 	return c.Create(context.TODO(), NewBrokerNamespace())
 }
 
+func DeleteBrokerNamespace(c client.Client) error {
+	err := c.Delete(context.TODO(), NewBrokerNamespace())
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
 func CreateOrUpdateClusterBrokerRole(c client.Client) error {
 	role := &rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: submarinerBrokerClusterRole, Namespace: consts.SubmarinerBrokerNamespace}}
 
@@ -202,6 +250,20 @@ func CreateOrUpdateBrokerAdminRole(c client.Client) error {
 	return nil
 }
 
+func CreateOrUpdateBrokerInfoReaderRole(c client.Client) error {
+	role := &rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: SubmarinerBrokerInfoReaderRole, Namespace: consts.SubmarinerBrokerNamespace}}
+
+	or, err := ctrl.CreateOrUpdate(context.TODO(), c, role, func() error {
+		return NewBrokerInfoReaderRole(role)
+	})
+	if err != nil {
+		klog.Errorf("Failed to %s role %s: %v", or, role.GetName(), err)
+		return err
+	}
+	klog.Infof("Role %s %s", role.GetName(), or)
+	return nil
+}
+
 func CreateNewBrokerRoleBinding(c client.Client, serviceAccount, role string) error {
 	return c.Create(context.TODO(), NewBrokerRoleBinding(serviceAccount, role))
 }
@@ -231,6 +293,18 @@ func NewBrokerClusterRole(role *rbacv1.Role) error {
 	return nil
 }
 
+func NewBrokerInfoReaderRole(role *rbacv1.Role) error {
+	role.Rules = []rbacv1.PolicyRule{
+		{
+			Verbs:         []string{"get"},
+			APIGroups:     []string{""},
+			Resources:     []string{"secrets"},
+			ResourceNames: []string{consts.SubmarinerBrokerInfo},
+		},
+	}
+	return nil
+}
+
 func NewBrokerAdminRole(role *rbacv1.Role) error {
 	role.Rules = []rbacv1.PolicyRule{
 		{
@@ -248,6 +322,11 @@ func NewBrokerAdminRole(role *rbacv1.Role) error {
 			APIGroups: []string{"rbac.authorization.k8s.io"},
 			Resources: []string{"rolebindings"},
 		},
+		{
+			Verbs:     []string{"create", "get", "list", "watch", "patch", "update", "delete"},
+			APIGroups: []string{"operator.tkestack.io"},
+			Resources: []string{"globalcidrallocations", "globalcidrallocations/status"},
+		},
 		{
 			Verbs:     []string{"create", "get", "list", "watch", "patch", "update", "delete"},
 			APIGroups: []string{"multicluster.x-k8s.io"},
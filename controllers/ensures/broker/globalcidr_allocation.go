@@ -0,0 +1,463 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package broker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	submarinerv1 "github.com/submariner-io/submariner/pkg/apis/submariner.io/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	operatorv1alpha1 "github.com/DanielXLee/cluster-fabric-operator/api/v1alpha1"
+)
+
+// DefaultGlobalCIDRReleaseGracePeriod is how long the global CIDRs of a departed cluster are kept before reuse,
+// when the broker does not set it
+const DefaultGlobalCIDRReleaseGracePeriod = time.Hour
+
+var globalCIDRAllocationResource = schema.GroupResource{
+	Group:    operatorv1alpha1.GroupVersion.Group,
+	Resource: "globalcidrallocations",
+}
+
+// ListClusterInfos returns the global CIDRs allocated to each cluster, from the GlobalCIDRAllocations of the broker
+// and from the globalnet configmap for the clusters not migrated yet, sorted by cluster ID
+func ListClusterInfos(reader client.Reader, namespace string) ([]ClusterInfo, error) {
+	clusters := map[string]ClusterInfo{}
+
+	configMap, err := GetGlobalnetConfigMap(reader, namespace)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+	if err == nil {
+		var clusterInfo []ClusterInfo
+		if err := json.Unmarshal([]byte(configMap.Data[ClusterInfoKey]), &clusterInfo); err != nil {
+			klog.Errorf("error reading globalnet clusterInfo: %v", err)
+			return nil, err
+		}
+		for _, info := range clusterInfo {
+			clusters[info.ClusterID] = info
+		}
+	}
+
+	allocations := &operatorv1alpha1.GlobalCIDRAllocationList{}
+	if err := reader.List(context.TODO(), allocations, client.InNamespace(namespace)); err != nil && !meta.IsNoMatchError(err) {
+		return nil, err
+	}
+	for _, allocation := range allocations.Items {
+		clusters[allocation.Spec.ClusterID] = ClusterInfo{
+			ClusterID:  allocation.Spec.ClusterID,
+			GlobalCidr: allocation.Spec.GlobalCIDRs,
+		}
+	}
+
+	clusterInfo := make([]ClusterInfo, 0, len(clusters))
+	for _, info := range clusters {
+		clusterInfo = append(clusterInfo, info)
+	}
+	sort.Slice(clusterInfo, func(i, j int) bool {
+		return clusterInfo[i].ClusterID < clusterInfo[j].ClusterID
+	})
+	return clusterInfo, nil
+}
+
+// RecordGlobalCIDRs records the global CIDRs allocated to a cluster in its GlobalCIDRAllocation, or in the globalnet
+// configmap when the broker has no GlobalCIDRAllocation CRD. A conflict is returned when a concurrent join
+// allocated overlapping CIDRs first, the allocation must then be retried.
+func RecordGlobalCIDRs(c client.Client, reader client.Reader, namespace string, configMap *v1.ConfigMap,
+	cluster ClusterInfo, owner *operatorv1alpha1.AllocationOwner) error {
+	allocation := &operatorv1alpha1.GlobalCIDRAllocation{}
+	allocationKey := types.NamespacedName{Name: cluster.ClusterID, Namespace: namespace}
+	err := reader.Get(context.TODO(), allocationKey, allocation)
+	switch {
+	case meta.IsNoMatchError(err):
+		klog.Info("The broker has no GlobalCIDRAllocation CRD, recording the global CIDRs in the globalnet configmap")
+		return UpdateGlobalnetConfigMap(c, namespace, configMap, cluster)
+	case apierrors.IsNotFound(err):
+		allocation = newGlobalCIDRAllocation(namespace, cluster, owner)
+		if err := c.Create(context.TODO(), allocation); err != nil {
+			if apierrors.IsAlreadyExists(err) {
+				return apierrors.NewConflict(globalCIDRAllocationResource, cluster.ClusterID, err)
+			}
+			return err
+		}
+		if err := checkAllocationConflicts(c, reader, allocation); err != nil {
+			return err
+		}
+	case err != nil:
+		return err
+	default:
+		if equality.Semantic.DeepEqual(allocation.Spec.GlobalCIDRs, cluster.GlobalCidr) &&
+			equality.Semantic.DeepEqual(allocation.Spec.Owner, owner) &&
+			allocation.Status.Phase == operatorv1alpha1.AllocationPhaseAllocated {
+			return nil
+		}
+		previous := allocation.Spec.GlobalCIDRs
+		allocation.Spec.GlobalCIDRs = cluster.GlobalCidr
+		allocation.Spec.Owner = owner
+		if err := c.Update(context.TODO(), allocation); err != nil {
+			return err
+		}
+		if err := checkUpdatedAllocationConflicts(c, reader, allocation, previous); err != nil {
+			return err
+		}
+	}
+	klog.Infof("GlobalCIDRAllocation %s records the global CIDRs %v", allocation.GetName(), cluster.GlobalCidr)
+
+	now := metav1.Now()
+	allocation.Status.Phase = operatorv1alpha1.AllocationPhaseAllocated
+	allocation.Status.AllocatedTime = &now
+	allocation.Status.ReleasedTime = nil
+	return c.Status().Update(context.TODO(), allocation)
+}
+
+// ReleaseGlobalCIDRs marks the GlobalCIDRAllocation of a cluster, if any, as released. The global CIDRs are kept
+// until the release grace period is over, so the cluster gets them back if it re-joins in the meantime. An
+// allocation requested by another owner is left as is.
+func ReleaseGlobalCIDRs(c client.Client, reader client.Reader, namespace, clusterID string,
+	owner *operatorv1alpha1.AllocationOwner) error {
+	allocation := &operatorv1alpha1.GlobalCIDRAllocation{}
+	allocationKey := types.NamespacedName{Name: clusterID, Namespace: namespace}
+	if err := reader.Get(context.TODO(), allocationKey, allocation); err != nil {
+		if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			return nil
+		}
+		return err
+	}
+	if !ownsAllocation(owner, allocation) {
+		klog.Infof("GlobalCIDRAllocation %s is owned by %v, not releasing it", allocation.GetName(), allocation.Spec.Owner)
+		return nil
+	}
+	return releaseAllocation(c, allocation)
+}
+
+// ownsAllocation tells whether the owner may release the allocation. The migrated allocations have no owner, and
+// the allocations recorded before the managed clusters owned theirs name the fabric only.
+func ownsAllocation(owner *operatorv1alpha1.AllocationOwner, allocation *operatorv1alpha1.GlobalCIDRAllocation) bool {
+	allocationOwner := allocation.Spec.Owner
+	if owner == nil || allocationOwner == nil {
+		return true
+	}
+	return allocationOwner.Name == owner.Name && allocationOwner.Namespace == owner.Namespace &&
+		(allocationOwner.ClusterID == "" || allocationOwner.ClusterID == owner.ClusterID)
+}
+
+// CollectGlobalCIDRAllocations releases the global CIDRs of the clusters which left the broker without un-joining,
+// and deletes the released allocations once the grace period is over so their global CIDRs can be reused. The
+// globalnet configmap entries are collected alike on the brokers without the GlobalCIDRAllocation CRD.
+func CollectGlobalCIDRAllocations(c client.Client, reader client.Reader, namespace string, gracePeriod time.Duration) error {
+	allocations := &operatorv1alpha1.GlobalCIDRAllocationList{}
+	if err := reader.List(context.TODO(), allocations, client.InNamespace(namespace)); err != nil {
+		if meta.IsNoMatchError(err) {
+			return collectGlobalnetConfigMap(c, reader, namespace, gracePeriod)
+		}
+		return err
+	}
+	if len(allocations.Items) == 0 {
+		return nil
+	}
+	joined, err := joinedClusterIDs(reader, namespace)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for i := range allocations.Items {
+		allocation := &allocations.Items[i]
+		switch allocation.Status.Phase {
+		case operatorv1alpha1.AllocationPhaseReleased:
+			released := allocation.Status.ReleasedTime
+			if released != nil && now.Before(released.Add(gracePeriod)) {
+				continue
+			}
+			klog.Infof("Reclaiming the global CIDRs %v of cluster %s", allocation.Spec.GlobalCIDRs, allocation.Spec.ClusterID)
+			if err := c.Delete(context.TODO(), allocation); err != nil && !apierrors.IsNotFound(err) {
+				return err
+			}
+		default:
+			if joined[allocation.Spec.ClusterID] {
+				continue
+			}
+			// A cluster has no service account on the broker until it joined, leave it the grace period to do so
+			allocated := allocation.GetCreationTimestamp()
+			if allocation.Status.AllocatedTime != nil {
+				allocated = *allocation.Status.AllocatedTime
+			}
+			if now.Before(allocated.Add(gracePeriod)) {
+				continue
+			}
+			klog.Infof("Cluster %s left the broker", allocation.Spec.ClusterID)
+			if err := releaseAllocation(c, allocation); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// collectGlobalnetConfigMap is CollectGlobalCIDRAllocations for the entries of the globalnet configmap
+func collectGlobalnetConfigMap(c client.Client, reader client.Reader, namespace string, gracePeriod time.Duration) error {
+	configMap, err := GetGlobalnetConfigMap(reader, namespace)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	var clusterInfo []ClusterInfo
+	if err := json.Unmarshal([]byte(configMap.Data[ClusterInfoKey]), &clusterInfo); err != nil {
+		klog.Errorf("error reading globalnet clusterInfo: %v", err)
+		return err
+	}
+	if len(clusterInfo) == 0 {
+		return nil
+	}
+	joined, err := joinedClusterIDs(reader, namespace)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	collectedTime := metav1.NewTime(now)
+	changed := false
+	remaining := make([]ClusterInfo, 0, len(clusterInfo))
+	for _, info := range clusterInfo {
+		switch {
+		case info.ReleasedTime != nil:
+			if now.After(info.ReleasedTime.Add(gracePeriod)) {
+				klog.Infof("Reclaiming the global CIDRs %v of cluster %s", info.GlobalCidr, info.ClusterID)
+				changed = true
+				continue
+			}
+		case joined[info.ClusterID]:
+		case info.AllocatedTime == nil:
+			// Written by another tool, the grace period to join starts now
+			info.AllocatedTime = &collectedTime
+			changed = true
+		case now.After(info.AllocatedTime.Add(gracePeriod)):
+			klog.Infof("Cluster %s left the broker, releasing its global CIDRs %v", info.ClusterID, info.GlobalCidr)
+			info.ReleasedTime = &collectedTime
+			changed = true
+		}
+		remaining = append(remaining, info)
+	}
+	if !changed {
+		return nil
+	}
+	return writeClusterInfo(c, configMap, remaining)
+}
+
+func releaseAllocation(c client.Client, allocation *operatorv1alpha1.GlobalCIDRAllocation) error {
+	if allocation.Status.Phase == operatorv1alpha1.AllocationPhaseReleased {
+		return nil
+	}
+	klog.Infof("Releasing the global CIDRs %v of cluster %s", allocation.Spec.GlobalCIDRs, allocation.Spec.ClusterID)
+	now := metav1.Now()
+	allocation.Status.Phase = operatorv1alpha1.AllocationPhaseReleased
+	allocation.Status.ReleasedTime = &now
+	return c.Status().Update(context.TODO(), allocation)
+}
+
+// joinedClusterIDs returns the clusters with a service account or a Cluster object on the broker
+func joinedClusterIDs(reader client.Reader, namespace string) (map[string]bool, error) {
+	joined := map[string]bool{}
+	serviceAccounts := &v1.ServiceAccountList{}
+	if err := reader.List(context.TODO(), serviceAccounts, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	clusterSAPrefix := strings.TrimSuffix(submarinerBrokerClusterSAFmt, "%s")
+	for _, sa := range serviceAccounts.Items {
+		if strings.HasPrefix(sa.Name, clusterSAPrefix) {
+			joined[strings.TrimPrefix(sa.Name, clusterSAPrefix)] = true
+		}
+	}
+
+	clusters := &submarinerv1.ClusterList{}
+	if err := reader.List(context.TODO(), clusters, client.InNamespace(namespace)); err != nil && !meta.IsNoMatchError(err) {
+		return nil, err
+	}
+	for _, cluster := range clusters.Items {
+		joined[cluster.Spec.ClusterID] = true
+	}
+	return joined, nil
+}
+
+// MigrateGlobalnetConfigMap moves the global CIDRs allocated in the globalnet configmap to GlobalCIDRAllocations,
+// the configmap is left untouched when the broker has no GlobalCIDRAllocation CRD
+func MigrateGlobalnetConfigMap(c client.Client, reader client.Reader, namespace string) error {
+	configMap, err := GetGlobalnetConfigMap(reader, namespace)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	var clusterInfo []ClusterInfo
+	if data := configMap.Data[ClusterInfoKey]; data != "" {
+		if err := json.Unmarshal([]byte(data), &clusterInfo); err != nil {
+			klog.Errorf("error reading globalnet clusterInfo: %v", err)
+			return err
+		}
+	}
+	if len(clusterInfo) == 0 {
+		return nil
+	}
+
+	for _, info := range clusterInfo {
+		allocation := newGlobalCIDRAllocation(namespace, info, nil)
+		if err := c.Create(context.TODO(), allocation); err != nil {
+			if meta.IsNoMatchError(err) {
+				klog.Info("The broker has no GlobalCIDRAllocation CRD, keeping the allocations in the globalnet configmap")
+				return nil
+			}
+			if apierrors.IsAlreadyExists(err) {
+				// The cluster re-joined since, its GlobalCIDRAllocation is authoritative
+				continue
+			}
+			return err
+		}
+		allocation.Status.Phase = operatorv1alpha1.AllocationPhaseAllocated
+		allocation.Status.AllocatedTime = &allocation.CreationTimestamp
+		if info.ReleasedTime != nil {
+			allocation.Status.Phase = operatorv1alpha1.AllocationPhaseReleased
+			allocation.Status.ReleasedTime = info.ReleasedTime
+		}
+		allocation.Status.Migrated = true
+		if err := c.Status().Update(context.TODO(), allocation); err != nil {
+			return err
+		}
+		klog.Infof("Migrated the global CIDRs %v of cluster %s from the globalnet configmap", info.GlobalCidr, info.ClusterID)
+	}
+
+	configMap.Data[ClusterInfoKey] = "[]"
+	return c.Update(context.TODO(), configMap)
+}
+
+func newGlobalCIDRAllocation(namespace string, cluster ClusterInfo, owner *operatorv1alpha1.AllocationOwner) *operatorv1alpha1.GlobalCIDRAllocation {
+	return &operatorv1alpha1.GlobalCIDRAllocation{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      cluster.ClusterID,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"component": "submariner-globalnet",
+			},
+		},
+		Spec: operatorv1alpha1.GlobalCIDRAllocationSpec{
+			ClusterID:   cluster.ClusterID,
+			GlobalCIDRs: cluster.GlobalCidr,
+			Owner:       owner,
+		},
+	}
+}
+
+// checkAllocationConflicts backs the new allocation out when another one, created before it, overlaps it
+func checkAllocationConflicts(c client.Client, reader client.Reader, allocation *operatorv1alpha1.GlobalCIDRAllocation) error {
+	other, err := findOverlappingAllocation(reader, allocation, true)
+	if err != nil || other == nil {
+		return err
+	}
+	klog.Infof("Global CIDRs %v of cluster %s overlap the ones of cluster %s, backing out",
+		allocation.Spec.GlobalCIDRs, allocation.Spec.ClusterID, other.Spec.ClusterID)
+	if err := c.Delete(context.TODO(), allocation); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return newAllocationConflict(allocation, other)
+}
+
+// checkUpdatedAllocationConflicts restores the former global CIDRs of the updated allocation when another one
+// overlaps the new ones. The updated allocation may be older than the one it overlaps, which keeps its CIDRs.
+func checkUpdatedAllocationConflicts(c client.Client, reader client.Reader, allocation *operatorv1alpha1.GlobalCIDRAllocation,
+	previous []string) error {
+	other, err := findOverlappingAllocation(reader, allocation, false)
+	if err != nil || other == nil {
+		return err
+	}
+	klog.Infof("Global CIDRs %v of cluster %s overlap the ones of cluster %s, restoring %v",
+		allocation.Spec.GlobalCIDRs, allocation.Spec.ClusterID, other.Spec.ClusterID, previous)
+	conflict := newAllocationConflict(allocation, other)
+	allocation.Spec.GlobalCIDRs = previous
+	if err := c.Update(context.TODO(), allocation); err != nil {
+		return err
+	}
+	return conflict
+}
+
+// findOverlappingAllocation returns another allocation overlapping the global CIDRs of the allocation, only among
+// the ones created before it when olderOnly is set
+func findOverlappingAllocation(reader client.Reader, allocation *operatorv1alpha1.GlobalCIDRAllocation,
+	olderOnly bool) (*operatorv1alpha1.GlobalCIDRAllocation, error) {
+	allocations := &operatorv1alpha1.GlobalCIDRAllocationList{}
+	if err := reader.List(context.TODO(), allocations, client.InNamespace(allocation.GetNamespace())); err != nil {
+		return nil, err
+	}
+	for i := range allocations.Items {
+		other := &allocations.Items[i]
+		if other.GetName() == allocation.GetName() || (olderOnly && !isOlder(other, allocation)) ||
+			!overlappingCIDRs(other.Spec.GlobalCIDRs, allocation.Spec.GlobalCIDRs) {
+			continue
+		}
+		return other, nil
+	}
+	return nil, nil
+}
+
+func newAllocationConflict(allocation, other *operatorv1alpha1.GlobalCIDRAllocation) error {
+	return apierrors.NewConflict(globalCIDRAllocationResource, allocation.GetName(),
+		fmt.Errorf("global CIDRs %v overlap the ones of cluster %s", allocation.Spec.GlobalCIDRs, other.Spec.ClusterID))
+}
+
+// isOlder orders the allocations by creation, the names break the ties of the allocations created the same second
+func isOlder(allocation, than *operatorv1alpha1.GlobalCIDRAllocation) bool {
+	created, thanCreated := allocation.GetCreationTimestamp(), than.GetCreationTimestamp()
+	if !created.Equal(&thanCreated) {
+		return created.Before(&thanCreated)
+	}
+	return allocation.GetName() < than.GetName()
+}
+
+func overlappingCIDRs(cidrs, others []string) bool {
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			continue
+		}
+		for _, other := range others {
+			_, otherNetwork, err := net.ParseCIDR(other)
+			if err != nil {
+				continue
+			}
+			if network.Contains(otherNetwork.IP) || otherNetwork.Contains(network.IP) {
+				return true
+			}
+		}
+	}
+	return false
+}
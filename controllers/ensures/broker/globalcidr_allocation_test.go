@@ -0,0 +1,219 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package broker
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	submarinerv1 "github.com/submariner-io/submariner/pkg/apis/submariner.io/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	operatorv1alpha1 "github.com/DanielXLee/cluster-fabric-operator/api/v1alpha1"
+)
+
+var _ = Describe("GlobalCIDRAllocation", func() {
+	var scheme *runtime.Scheme
+	owner := &operatorv1alpha1.AllocationOwner{Name: "fabric", Namespace: "default"}
+
+	BeforeEach(func() {
+		scheme = runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(submarinerv1.AddToScheme(scheme)).To(Succeed())
+		Expect(operatorv1alpha1.AddToScheme(scheme)).To(Succeed())
+	})
+
+	getAllocation := func(c client.Client, clusterID string) (*operatorv1alpha1.GlobalCIDRAllocation, error) {
+		allocation := &operatorv1alpha1.GlobalCIDRAllocation{}
+		key := types.NamespacedName{Name: clusterID, Namespace: SubmarinerBrokerNamespace}
+		return allocation, c.Get(context.TODO(), key, allocation)
+	}
+
+	When("Migrating the globalnet configmap", func() {
+		It("Should move every cluster to its own GlobalCIDRAllocation", func() {
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(newGlobalnetConfigMap(
+				ClusterInfo{ClusterID: "cluster1", GlobalCidr: []string{"242.0.0.0/16"}},
+				ClusterInfo{ClusterID: "cluster2", GlobalCidr: []string{"242.1.0.0/16"}},
+			)).Build()
+			Expect(MigrateGlobalnetConfigMap(c, c, SubmarinerBrokerNamespace)).To(Succeed())
+
+			allocation, err := getAllocation(c, "cluster1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(allocation.Spec.GlobalCIDRs).To(Equal([]string{"242.0.0.0/16"}))
+			Expect(allocation.Spec.Owner).To(BeNil())
+			Expect(allocation.Status.Migrated).To(BeTrue())
+			Expect(allocation.Status.Phase).To(Equal(operatorv1alpha1.AllocationPhaseAllocated))
+			Expect(getClusterInfo(c)).To(BeEmpty())
+
+			Expect(ListClusterInfos(c, SubmarinerBrokerNamespace)).To(Equal([]ClusterInfo{
+				{ClusterID: "cluster1", GlobalCidr: []string{"242.0.0.0/16"}},
+				{ClusterID: "cluster2", GlobalCidr: []string{"242.1.0.0/16"}},
+			}))
+		})
+	})
+
+	When("Listing the allocations before the migration", func() {
+		It("Should merge the configmap and the GlobalCIDRAllocations", func() {
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+				newGlobalnetConfigMap(ClusterInfo{ClusterID: "cluster1", GlobalCidr: []string{"242.0.0.0/16"}}),
+				newGlobalCIDRAllocation(SubmarinerBrokerNamespace, ClusterInfo{ClusterID: "cluster2", GlobalCidr: []string{"242.1.0.0/16"}}, owner),
+			).Build()
+			Expect(ListClusterInfos(c, SubmarinerBrokerNamespace)).To(HaveLen(2))
+		})
+	})
+
+	When("Recording the global CIDRs of a cluster", func() {
+		It("Should create then update its GlobalCIDRAllocation", func() {
+			c := fake.NewClientBuilder().WithScheme(scheme).Build()
+			cluster := ClusterInfo{ClusterID: "cluster1", GlobalCidr: []string{"242.0.0.0/16"}}
+			Expect(RecordGlobalCIDRs(c, c, SubmarinerBrokerNamespace, nil, cluster, owner)).To(Succeed())
+
+			allocation, err := getAllocation(c, "cluster1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(allocation.Spec.Owner).To(Equal(owner))
+			Expect(allocation.Status.AllocatedTime).NotTo(BeNil())
+
+			cluster.GlobalCidr = []string{"242.2.0.0/16"}
+			Expect(RecordGlobalCIDRs(c, c, SubmarinerBrokerNamespace, nil, cluster, owner)).To(Succeed())
+			allocation, err = getAllocation(c, "cluster1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(allocation.Spec.GlobalCIDRs).To(Equal([]string{"242.2.0.0/16"}))
+		})
+
+		It("Should back out when an earlier allocation overlaps", func() {
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+				newGlobalCIDRAllocation(SubmarinerBrokerNamespace, ClusterInfo{ClusterID: "a-cluster", GlobalCidr: []string{"242.0.0.0/16"}}, owner),
+			).Build()
+			cluster := ClusterInfo{ClusterID: "cluster1", GlobalCidr: []string{"242.0.0.0/16"}}
+			err := RecordGlobalCIDRs(c, c, SubmarinerBrokerNamespace, nil, cluster, owner)
+			Expect(apierrors.IsConflict(err)).To(BeTrue())
+
+			_, err = getAllocation(c, "cluster1")
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("Should restore the former CIDRs when the updated ones overlap another allocation", func() {
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+				newGlobalCIDRAllocation(SubmarinerBrokerNamespace, ClusterInfo{ClusterID: "cluster1", GlobalCidr: []string{"242.0.0.0/16"}}, owner),
+				newGlobalCIDRAllocation(SubmarinerBrokerNamespace, ClusterInfo{ClusterID: "cluster2", GlobalCidr: []string{"242.1.0.0/16"}}, owner),
+			).Build()
+			cluster := ClusterInfo{ClusterID: "cluster1", GlobalCidr: []string{"242.1.0.0/17"}}
+			err := RecordGlobalCIDRs(c, c, SubmarinerBrokerNamespace, nil, cluster, owner)
+			Expect(apierrors.IsConflict(err)).To(BeTrue())
+
+			allocation, err := getAllocation(c, "cluster1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(allocation.Spec.GlobalCIDRs).To(Equal([]string{"242.0.0.0/16"}))
+		})
+	})
+
+	When("Releasing the global CIDRs of a cluster", func() {
+		It("Should keep its GlobalCIDRAllocation until it re-joins", func() {
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+				newGlobalCIDRAllocation(SubmarinerBrokerNamespace, ClusterInfo{ClusterID: "cluster1", GlobalCidr: []string{"242.0.0.0/16"}}, owner),
+			).Build()
+			Expect(ReleaseGlobalCIDRs(c, c, SubmarinerBrokerNamespace, "cluster1", owner)).To(Succeed())
+			allocation, err := getAllocation(c, "cluster1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(allocation.Status.Phase).To(Equal(operatorv1alpha1.AllocationPhaseReleased))
+			Expect(allocation.Status.ReleasedTime).NotTo(BeNil())
+			Expect(ReleaseGlobalCIDRs(c, c, SubmarinerBrokerNamespace, "cluster2", owner)).To(Succeed())
+
+			cluster := ClusterInfo{ClusterID: "cluster1", GlobalCidr: []string{"242.0.0.0/16"}}
+			Expect(RecordGlobalCIDRs(c, c, SubmarinerBrokerNamespace, nil, cluster, owner)).To(Succeed())
+			allocation, err = getAllocation(c, "cluster1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(allocation.Status.Phase).To(Equal(operatorv1alpha1.AllocationPhaseAllocated))
+			Expect(allocation.Status.ReleasedTime).To(BeNil())
+		})
+
+		It("Should release the allocation of the departing managed cluster only", func() {
+			owner1 := &operatorv1alpha1.AllocationOwner{Name: "fabric", Namespace: "default", ClusterID: "cluster1"}
+			owner2 := &operatorv1alpha1.AllocationOwner{Name: "fabric", Namespace: "default", ClusterID: "cluster2"}
+			c := fake.NewClientBuilder().WithScheme(scheme).Build()
+			cluster1 := ClusterInfo{ClusterID: "cluster1", GlobalCidr: []string{"242.0.0.0/16"}}
+			Expect(RecordGlobalCIDRs(c, c, SubmarinerBrokerNamespace, nil, cluster1, owner1)).To(Succeed())
+			cluster2 := ClusterInfo{ClusterID: "cluster2", GlobalCidr: []string{"242.1.0.0/16"}}
+			Expect(RecordGlobalCIDRs(c, c, SubmarinerBrokerNamespace, nil, cluster2, owner2)).To(Succeed())
+
+			Expect(ReleaseGlobalCIDRs(c, c, SubmarinerBrokerNamespace, "cluster2", owner1)).To(Succeed())
+			allocation, err := getAllocation(c, "cluster2")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(allocation.Spec.Owner).To(Equal(owner2))
+			Expect(allocation.Status.Phase).To(Equal(operatorv1alpha1.AllocationPhaseAllocated))
+
+			Expect(ReleaseGlobalCIDRs(c, c, SubmarinerBrokerNamespace, "cluster1", owner1)).To(Succeed())
+			allocation, err = getAllocation(c, "cluster1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(allocation.Status.Phase).To(Equal(operatorv1alpha1.AllocationPhaseReleased))
+			allocation, err = getAllocation(c, "cluster2")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(allocation.Status.Phase).To(Equal(operatorv1alpha1.AllocationPhaseAllocated))
+		})
+	})
+
+	When("Collecting the allocations of departed clusters", func() {
+		past := metav1.NewTime(time.Now().Add(-2 * time.Hour))
+		newAllocation := func(clusterID, cidr string, phase operatorv1alpha1.AllocationPhase) *operatorv1alpha1.GlobalCIDRAllocation {
+			allocation := newGlobalCIDRAllocation(SubmarinerBrokerNamespace, ClusterInfo{ClusterID: clusterID, GlobalCidr: []string{cidr}}, owner)
+			allocation.Status.Phase = phase
+			allocation.Status.AllocatedTime = &past
+			if phase == operatorv1alpha1.AllocationPhaseReleased {
+				allocation.Status.ReleasedTime = &past
+			}
+			return allocation
+		}
+
+		It("Should release the allocations of the clusters without a service account", func() {
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+				newAllocation("cluster1", "242.0.0.0/16", operatorv1alpha1.AllocationPhaseAllocated),
+				newAllocation("cluster2", "242.1.0.0/16", operatorv1alpha1.AllocationPhaseAllocated),
+				&v1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "cluster-cluster2", Namespace: SubmarinerBrokerNamespace}},
+			).Build()
+			Expect(CollectGlobalCIDRAllocations(c, c, SubmarinerBrokerNamespace, time.Hour)).To(Succeed())
+
+			allocation, err := getAllocation(c, "cluster1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(allocation.Status.Phase).To(Equal(operatorv1alpha1.AllocationPhaseReleased))
+			allocation, err = getAllocation(c, "cluster2")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(allocation.Status.Phase).To(Equal(operatorv1alpha1.AllocationPhaseAllocated))
+		})
+
+		It("Should reclaim the released allocations after the grace period only", func() {
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+				newAllocation("cluster1", "242.0.0.0/16", operatorv1alpha1.AllocationPhaseReleased),
+			).Build()
+			Expect(CollectGlobalCIDRAllocations(c, c, SubmarinerBrokerNamespace, 3*time.Hour)).To(Succeed())
+			_, err := getAllocation(c, "cluster1")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(CollectGlobalCIDRAllocations(c, c, SubmarinerBrokerNamespace, time.Hour)).To(Succeed())
+			_, err = getAllocation(c, "cluster1")
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+})
@@ -19,6 +19,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"reflect"
 
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -39,6 +40,10 @@ const (
 type ClusterInfo struct {
 	ClusterID  string   `json:"cluster_id"`
 	GlobalCidr []string `json:"global_cidr"`
+	// AllocatedTime and ReleasedTime stand for the GlobalCIDRAllocation status on the brokers without the
+	// GlobalCIDRAllocation CRD, the other tools writing the configmap leave them unset
+	AllocatedTime *metav1.Time `json:"allocated_time,omitempty"`
+	ReleasedTime  *metav1.Time `json:"released_time,omitempty"`
 }
 
 func CreateGlobalnetConfigMap(c client.Client, globalnetEnabled bool, defaultGlobalCidrRange string,
@@ -69,18 +74,24 @@ func GeneralGlobalnetConfigMap(cm *v1.ConfigMap, globalnetEnabled bool, defaultG
 		return err
 	}
 
+	// Keep the global CIDRs already allocated, across reconciles and globalnet being switched off and on again
+	clusterInfo := cm.Data[ClusterInfoKey]
+	if clusterInfo == "" {
+		clusterInfo = "[]"
+	}
+
 	var data map[string]string
 	if globalnetEnabled {
 		data = map[string]string{
 			GlobalnetStatusKey:   "true",
 			GlobalnetCidrRange:   string(cidrRange),
 			GlobalnetClusterSize: fmt.Sprint(defaultGlobalClusterSize),
-			ClusterInfoKey:       "[]",
+			ClusterInfoKey:       clusterInfo,
 		}
 	} else {
 		data = map[string]string{
 			GlobalnetStatusKey: "false",
-			ClusterInfoKey:     "[]",
+			ClusterInfoKey:     clusterInfo,
 		}
 	}
 	cm.ObjectMeta.Labels = labels
@@ -96,10 +107,17 @@ func UpdateGlobalnetConfigMap(c client.Client, namespace string,
 		return err
 	}
 
+	now := metav1.Now()
 	exists := false
 	for k, value := range clusterInfo {
 		if value.ClusterID == newCluster.ClusterID {
+			if reflect.DeepEqual(value.GlobalCidr, newCluster.GlobalCidr) && value.ReleasedTime == nil {
+				return nil
+			}
+			// A released cluster which re-joins within the grace period gets its global CIDRs back
 			clusterInfo[k].GlobalCidr = newCluster.GlobalCidr
+			clusterInfo[k].AllocatedTime = &now
+			clusterInfo[k].ReleasedTime = nil
 			exists = true
 		}
 	}
@@ -108,9 +126,56 @@ func UpdateGlobalnetConfigMap(c client.Client, namespace string,
 		var newEntry ClusterInfo
 		newEntry.ClusterID = newCluster.ClusterID
 		newEntry.GlobalCidr = newCluster.GlobalCidr
+		newEntry.AllocatedTime = &now
 		clusterInfo = append(clusterInfo, newEntry)
 	}
 
+	return writeClusterInfo(c, configMap, clusterInfo)
+}
+
+// ReleaseClusterInGlobalnetConfigMap marks the global CIDRs of a cluster as released in the globalnet configmap,
+// they are kept until the release grace period is over, as the ones of a GlobalCIDRAllocation are
+func ReleaseClusterInGlobalnetConfigMap(c client.Client, configMap *v1.ConfigMap, clusterID string) error {
+	var clusterInfo []ClusterInfo
+	if err := json.Unmarshal([]byte(configMap.Data[ClusterInfoKey]), &clusterInfo); err != nil {
+		return err
+	}
+
+	released := false
+	for k, value := range clusterInfo {
+		if value.ClusterID == clusterID && value.ReleasedTime == nil {
+			klog.Infof("Releasing the global CIDRs %v of cluster %s", value.GlobalCidr, clusterID)
+			now := metav1.Now()
+			clusterInfo[k].ReleasedTime = &now
+			released = true
+		}
+	}
+	if !released {
+		return nil
+	}
+	return writeClusterInfo(c, configMap, clusterInfo)
+}
+
+func DeleteClusterFromGlobalnetConfigMap(c client.Client, configMap *v1.ConfigMap, clusterID string) error {
+	var clusterInfo []ClusterInfo
+	err := json.Unmarshal([]byte(configMap.Data[ClusterInfoKey]), &clusterInfo)
+	if err != nil {
+		return err
+	}
+
+	remaining := make([]ClusterInfo, 0, len(clusterInfo))
+	for _, value := range clusterInfo {
+		if value.ClusterID != clusterID {
+			remaining = append(remaining, value)
+		}
+	}
+	if len(remaining) == len(clusterInfo) {
+		return nil
+	}
+	return writeClusterInfo(c, configMap, remaining)
+}
+
+func writeClusterInfo(c client.Client, configMap *v1.ConfigMap, clusterInfo []ClusterInfo) error {
 	data, err := json.MarshalIndent(clusterInfo, "", "\t")
 	if err != nil {
 		return err
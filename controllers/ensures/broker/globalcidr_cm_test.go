@@ -0,0 +1,140 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package broker
+
+import (
+	"encoding/json"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	submarinerv1 "github.com/submariner-io/submariner/pkg/apis/submariner.io/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newGlobalnetConfigMap(clusters ...ClusterInfo) *v1.ConfigMap {
+	cm := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      GlobalCIDRConfigMapName,
+			Namespace: SubmarinerBrokerNamespace,
+		},
+	}
+	Expect(GeneralGlobalnetConfigMap(cm, true, "242.0.0.0/8", 65536)).To(Succeed())
+	data, err := json.Marshal(clusters)
+	Expect(err).NotTo(HaveOccurred())
+	cm.Data[ClusterInfoKey] = string(data)
+	return cm
+}
+
+func getClusterInfo(c client.Client) []ClusterInfo {
+	cm, err := GetGlobalnetConfigMap(c, SubmarinerBrokerNamespace)
+	Expect(err).NotTo(HaveOccurred())
+	var clusterInfo []ClusterInfo
+	Expect(json.Unmarshal([]byte(cm.Data[ClusterInfoKey]), &clusterInfo)).To(Succeed())
+	return clusterInfo
+}
+
+var _ = Describe("DeleteClusterFromGlobalnetConfigMap", func() {
+	var c client.Client
+
+	BeforeEach(func() {
+		c = fake.NewClientBuilder().WithObjects(newGlobalnetConfigMap(
+			ClusterInfo{ClusterID: "cluster1", GlobalCidr: []string{"242.0.0.0/16"}},
+			ClusterInfo{ClusterID: "cluster2", GlobalCidr: []string{"242.1.0.0/16"}},
+		)).Build()
+	})
+
+	When("The cluster has a global CIDR allocated", func() {
+		It("Should remove only that cluster", func() {
+			cm, err := GetGlobalnetConfigMap(c, SubmarinerBrokerNamespace)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(DeleteClusterFromGlobalnetConfigMap(c, cm, "cluster1")).To(Succeed())
+			Expect(getClusterInfo(c)).To(Equal([]ClusterInfo{{ClusterID: "cluster2", GlobalCidr: []string{"242.1.0.0/16"}}}))
+		})
+	})
+
+	When("The cluster has no global CIDR allocated", func() {
+		It("Should leave the configmap untouched", func() {
+			cm, err := GetGlobalnetConfigMap(c, SubmarinerBrokerNamespace)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(DeleteClusterFromGlobalnetConfigMap(c, cm, "cluster3")).To(Succeed())
+			Expect(getClusterInfo(c)).To(HaveLen(2))
+		})
+	})
+})
+
+var _ = Describe("Collecting the globalnet configmap", func() {
+	var c client.Client
+
+	BeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(submarinerv1.AddToScheme(scheme)).To(Succeed())
+		past := metav1.NewTime(time.Now().Add(-2 * time.Hour))
+		c = fake.NewClientBuilder().WithScheme(scheme).WithObjects(newGlobalnetConfigMap(
+			ClusterInfo{ClusterID: "cluster1", GlobalCidr: []string{"242.0.0.0/16"}, AllocatedTime: &past},
+			ClusterInfo{ClusterID: "cluster2", GlobalCidr: []string{"242.1.0.0/16"}, AllocatedTime: &past},
+		), &v1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "cluster-cluster2", Namespace: SubmarinerBrokerNamespace}},
+		).Build()
+	})
+
+	It("Should keep a released cluster until the grace period is over", func() {
+		cm, err := GetGlobalnetConfigMap(c, SubmarinerBrokerNamespace)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ReleaseClusterInGlobalnetConfigMap(c, cm, "cluster2")).To(Succeed())
+		Expect(collectGlobalnetConfigMap(c, c, SubmarinerBrokerNamespace, time.Hour)).To(Succeed())
+		clusterInfo := getClusterInfo(c)
+		Expect(clusterInfo).To(HaveLen(2))
+		Expect(clusterInfo[1].ReleasedTime).NotTo(BeNil())
+
+		Expect(collectGlobalnetConfigMap(c, c, SubmarinerBrokerNamespace, 0)).To(Succeed())
+		Expect(getClusterInfo(c)).To(BeEmpty())
+	})
+
+	It("Should release the clusters without a service account", func() {
+		Expect(collectGlobalnetConfigMap(c, c, SubmarinerBrokerNamespace, time.Hour)).To(Succeed())
+		clusterInfo := getClusterInfo(c)
+		Expect(clusterInfo).To(HaveLen(2))
+		Expect(clusterInfo[0].ReleasedTime).NotTo(BeNil())
+		Expect(clusterInfo[1].ReleasedTime).To(BeNil())
+	})
+
+	It("Should hand its global CIDRs back to a re-joining cluster", func() {
+		cm, err := GetGlobalnetConfigMap(c, SubmarinerBrokerNamespace)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ReleaseClusterInGlobalnetConfigMap(c, cm, "cluster1")).To(Succeed())
+		cm, err = GetGlobalnetConfigMap(c, SubmarinerBrokerNamespace)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(UpdateGlobalnetConfigMap(c, SubmarinerBrokerNamespace, cm,
+			ClusterInfo{ClusterID: "cluster1", GlobalCidr: []string{"242.0.0.0/16"}})).To(Succeed())
+		Expect(getClusterInfo(c)[0].ReleasedTime).To(BeNil())
+	})
+})
+
+var _ = Describe("GeneralGlobalnetConfigMap", func() {
+	It("Should keep the global CIDRs allocated before globalnet was switched off and on again", func() {
+		cm := newGlobalnetConfigMap(ClusterInfo{ClusterID: "cluster1", GlobalCidr: []string{"242.0.0.0/16"}})
+		Expect(GeneralGlobalnetConfigMap(cm, false, "", 0)).To(Succeed())
+		Expect(GeneralGlobalnetConfigMap(cm, true, "242.0.0.0/8", 65536)).To(Succeed())
+		Expect(cm.Data[ClusterInfoKey]).To(ContainSubstring("242.0.0.0/16"))
+	})
+})
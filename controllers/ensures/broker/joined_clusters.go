@@ -0,0 +1,124 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package broker
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	submarinerv1 "github.com/submariner-io/submariner/pkg/apis/submariner.io/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	operatorv1alpha2 "github.com/DanielXLee/cluster-fabric-operator/api/v1alpha2"
+)
+
+// ListJoinedClusters collects the clusters joined to the broker from the cluster service accounts, and the
+// Cluster and Endpoint objects the clusters publish in the broker namespace, sorted by cluster ID
+func ListJoinedClusters(reader client.Reader, namespace string) ([]operatorv1alpha2.JoinedClusterStatus, error) {
+	joined := map[string]*operatorv1alpha2.JoinedClusterStatus{}
+	getCluster := func(clusterID string) *operatorv1alpha2.JoinedClusterStatus {
+		if joined[clusterID] == nil {
+			joined[clusterID] = &operatorv1alpha2.JoinedClusterStatus{ClusterID: clusterID}
+		}
+		return joined[clusterID]
+	}
+
+	saList := &v1.ServiceAccountList{}
+	if err := reader.List(context.TODO(), saList, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	clusterSAPrefix := strings.TrimSuffix(submarinerBrokerClusterSAFmt, "%s")
+	for i := range saList.Items {
+		sa := &saList.Items[i]
+		if strings.HasPrefix(sa.Name, clusterSAPrefix) {
+			cluster := getCluster(strings.TrimPrefix(sa.Name, clusterSAPrefix))
+			updateLastSeen(cluster, sa)
+		}
+	}
+
+	clusterList := &submarinerv1.ClusterList{}
+	if err := reader.List(context.TODO(), clusterList, client.InNamespace(namespace)); err != nil && !meta.IsNoMatchError(err) {
+		return nil, err
+	}
+	for i := range clusterList.Items {
+		submarinerCluster := &clusterList.Items[i]
+		cluster := getCluster(submarinerCluster.Spec.ClusterID)
+		cluster.GlobalCIDRs = submarinerCluster.Spec.GlobalCIDR
+		updateLastSeen(cluster, submarinerCluster)
+	}
+
+	endpointList := &submarinerv1.EndpointList{}
+	if err := reader.List(context.TODO(), endpointList, client.InNamespace(namespace)); err != nil && !meta.IsNoMatchError(err) {
+		return nil, err
+	}
+	for i := range endpointList.Items {
+		endpoint := &endpointList.Items[i]
+		cluster := getCluster(endpoint.Spec.ClusterID)
+		cluster.Gateways = append(cluster.Gateways, operatorv1alpha2.GatewayStatus{
+			Hostname:   endpoint.Spec.Hostname,
+			CableName:  endpoint.Spec.CableName,
+			Backend:    endpoint.Spec.Backend,
+			PrivateIP:  endpoint.Spec.PrivateIP,
+			PublicIP:   endpoint.Spec.PublicIP,
+			NATEnabled: endpoint.Spec.NATEnabled,
+		})
+		updateLastSeen(cluster, endpoint)
+	}
+
+	// The global CIDRs allocated by the broker take precedence over the ones the clusters advertise
+	clusterInfo, err := ListClusterInfos(reader, namespace)
+	if err != nil {
+		return nil, err
+	}
+	for _, info := range clusterInfo {
+		if cluster, ok := joined[info.ClusterID]; ok {
+			cluster.GlobalCIDRs = info.GlobalCidr
+		}
+	}
+
+	clusters := make([]operatorv1alpha2.JoinedClusterStatus, 0, len(joined))
+	for _, cluster := range joined {
+		sort.Slice(cluster.Gateways, func(i, j int) bool {
+			return cluster.Gateways[i].Hostname < cluster.Gateways[j].Hostname
+		})
+		clusters = append(clusters, *cluster)
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		return clusters[i].ClusterID < clusters[j].ClusterID
+	})
+	return clusters, nil
+}
+
+// updateLastSeen moves the last seen time of the cluster to the last time the object was written
+func updateLastSeen(cluster *operatorv1alpha2.JoinedClusterStatus, obj metav1.Object) {
+	lastSeen := obj.GetCreationTimestamp()
+	for _, field := range obj.GetManagedFields() {
+		if field.Time != nil && lastSeen.Before(field.Time) {
+			lastSeen = *field.Time
+		}
+	}
+	if lastSeen.IsZero() {
+		return
+	}
+	if cluster.LastSeen == nil || cluster.LastSeen.Before(&lastSeen) {
+		cluster.LastSeen = &lastSeen
+	}
+}
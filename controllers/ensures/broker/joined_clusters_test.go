@@ -0,0 +1,94 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package broker
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	submarinerv1 "github.com/submariner-io/submariner/pkg/apis/submariner.io/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	operatorv1alpha1 "github.com/DanielXLee/cluster-fabric-operator/api/v1alpha1"
+	operatorv1alpha2 "github.com/DanielXLee/cluster-fabric-operator/api/v1alpha2"
+)
+
+var _ = Describe("ListJoinedClusters", func() {
+	var scheme *runtime.Scheme
+
+	BeforeEach(func() {
+		scheme = runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(submarinerv1.AddToScheme(scheme)).To(Succeed())
+		Expect(operatorv1alpha1.AddToScheme(scheme)).To(Succeed())
+	})
+
+	When("Clusters joined the broker", func() {
+		It("Should report each cluster with its global CIDR and gateways", func() {
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+				&v1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "cluster-cluster1", Namespace: SubmarinerBrokerNamespace}},
+				&v1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "cluster-cluster2", Namespace: SubmarinerBrokerNamespace}},
+				&v1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: BrokerSA, Namespace: SubmarinerBrokerNamespace}},
+				&submarinerv1.Cluster{
+					ObjectMeta: metav1.ObjectMeta{Name: "cluster1", Namespace: SubmarinerBrokerNamespace},
+					Spec:       submarinerv1.ClusterSpec{ClusterID: "cluster1", GlobalCIDR: []string{"169.254.0.0/16"}},
+				},
+				&submarinerv1.Endpoint{
+					ObjectMeta: metav1.ObjectMeta{Name: "cluster1-submariner-cable", Namespace: SubmarinerBrokerNamespace},
+					Spec: submarinerv1.EndpointSpec{
+						ClusterID: "cluster1",
+						CableName: "submariner-cable-cluster1-10-0-0-1",
+						Hostname:  "node1",
+						PrivateIP: "10.0.0.1",
+						PublicIP:  "1.2.3.4",
+						Backend:   "libreswan",
+					},
+				},
+				newGlobalnetConfigMap(ClusterInfo{ClusterID: "cluster1", GlobalCidr: []string{"242.0.0.0/16"}}),
+			).Build()
+
+			clusters, err := ListJoinedClusters(c, SubmarinerBrokerNamespace)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(clusters).To(HaveLen(2))
+
+			Expect(clusters[0].ClusterID).To(Equal("cluster1"))
+			Expect(clusters[0].GlobalCIDRs).To(Equal([]string{"242.0.0.0/16"}))
+			Expect(clusters[0].Gateways).To(Equal([]operatorv1alpha2.GatewayStatus{{
+				Hostname:  "node1",
+				CableName: "submariner-cable-cluster1-10-0-0-1",
+				Backend:   "libreswan",
+				PrivateIP: "10.0.0.1",
+				PublicIP:  "1.2.3.4",
+			}}))
+
+			Expect(clusters[1].ClusterID).To(Equal("cluster2"))
+			Expect(clusters[1].Gateways).To(BeEmpty())
+		})
+	})
+
+	When("No cluster joined the broker", func() {
+		It("Should report no cluster", func() {
+			c := fake.NewClientBuilder().WithScheme(scheme).Build()
+			clusters, err := ListJoinedClusters(c, SubmarinerBrokerNamespace)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(clusters).To(BeEmpty())
+		})
+	})
+})
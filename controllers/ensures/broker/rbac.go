@@ -37,6 +37,11 @@ const (
 	SubmarinerBrokerAdminSA          = "submariner-k8s-broker-admin"
 	submarinerBrokerClusterSAFmt     = "cluster-%s"
 	submarinerBrokerClusterDefaultSA = "submariner-k8s-broker-client" // for backwards compatibility with documentation
+	// SubmarinerBrokerInfoReaderRole grants read access to the broker info secret only
+	SubmarinerBrokerInfoReaderRole = "submariner-k8s-broker-info-reader"
+	// SubmarinerBrokerInfoReaderSA is bound to the broker info reader role, its token goes into the broker
+	// kubeconfig handed to the clusters reading the broker info from the broker cluster
+	SubmarinerBrokerInfoReaderSA = "submariner-k8s-broker-info-reader"
 )
 
 func NewBrokerSA(submarinerBrokerSA string) *v1.ServiceAccount {
@@ -31,4 +31,16 @@ const (
 
 	//FabricNamespaceLabel is the label used to label the resource managed by fabric
 	FabricNamespaceLabel = "operator.tkestack.io/fabric-namespace"
+
+	// FabricFinalizer is the finalizer used to uninstall submariner when the fabric is deleted
+	FabricFinalizer = "operator.tkestack.io/fabric-finalizer"
+
+	// BrokerFinalizer is the finalizer used to uninstall the broker when the broker object is deleted
+	BrokerFinalizer = "operator.tkestack.io/broker-finalizer"
+
+	// ClusterJoinFinalizer is the finalizer used to uninstall submariner when the cluster join is deleted
+	ClusterJoinFinalizer = "operator.tkestack.io/clusterjoin-finalizer"
+
+	// FabricMigratedAnnotation marks the fabrics handed over to a broker and a cluster join of the same name
+	FabricMigratedAnnotation = "operator.tkestack.io/migrated"
 )
@@ -0,0 +1,147 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gateway
+
+import (
+	"context"
+
+	submarinerv1 "github.com/submariner-io/submariner/pkg/apis/submariner.io/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	operatorv1alpha2 "github.com/DanielXLee/cluster-fabric-operator/api/v1alpha2"
+	consts "github.com/DanielXLee/cluster-fabric-operator/controllers/ensures"
+)
+
+const (
+	// LoadBalancerName is the name of the LoadBalancer service in front of the gateway pods
+	LoadBalancerName = "submariner-gateway"
+
+	gatewayLabel       = "submariner.io/gateway"
+	nattDiscoveryPort  = 4490
+	publicIPAnnotation = submarinerv1.GatewayConfigPrefix + submarinerv1.PublicIP
+)
+
+// loadBalancerPublicIP makes the gateways resolve their public IP from the LoadBalancer service
+var loadBalancerPublicIP = submarinerv1.LoadBalancer + ":" + LoadBalancerName
+
+// EnsureLoadBalancer creates or updates the LoadBalancer service in front of the gateway pods, and points the
+// gateway nodes at it to resolve their public IP
+func EnsureLoadBalancer(c client.Client, instance *operatorv1alpha2.Fabric, namespace string) (*v1.Service, error) {
+	nattPort := int32(instance.Spec.JoinConfig.NattPort)
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      LoadBalancerName,
+			Namespace: namespace,
+		},
+	}
+	labels := make(map[string]string)
+	labels[consts.FabricNameLabel] = instance.GetName()
+	labels[consts.FabricNamespaceLabel] = instance.GetNamespace()
+
+	or, err := ctrl.CreateOrUpdate(context.TODO(), c, svc, func() error {
+		svc.ObjectMeta.Labels = labels
+		if svc.ObjectMeta.Annotations == nil {
+			svc.ObjectMeta.Annotations = map[string]string{}
+		}
+		svc.ObjectMeta.Annotations["service.beta.kubernetes.io/aws-load-balancer-type"] = "nlb"
+		svc.Spec.Type = v1.ServiceTypeLoadBalancer
+		svc.Spec.ExternalTrafficPolicy = v1.ServiceExternalTrafficPolicyTypeLocal
+		svc.Spec.Selector = map[string]string{"app": "submariner-gateway"}
+		svc.Spec.Ports = []v1.ServicePort{
+			{
+				Name:       "cable-encapsulation",
+				Protocol:   v1.ProtocolUDP,
+				Port:       nattPort,
+				TargetPort: intstr.FromInt(int(nattPort)),
+			},
+			{
+				Name:       "natt-discovery",
+				Protocol:   v1.ProtocolUDP,
+				Port:       nattDiscoveryPort,
+				TargetPort: intstr.FromInt(nattDiscoveryPort),
+			},
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	klog.Infof("Service %s %s", LoadBalancerName, or)
+	return svc, annotateGatewayNodes(c, true)
+}
+
+// DeleteLoadBalancer deletes the LoadBalancer service in front of the gateway pods, and lets the gateway nodes
+// resolve their public IP on their own again
+func DeleteLoadBalancer(c client.Client, namespace string) error {
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      LoadBalancerName,
+			Namespace: namespace,
+		},
+	}
+	if err := c.Delete(context.TODO(), svc); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return annotateGatewayNodes(c, false)
+}
+
+// LoadBalancerAddress returns the external address of the LoadBalancer service, or "" while it is pending
+func LoadBalancerAddress(svc *v1.Service) string {
+	for _, ingress := range svc.Status.LoadBalancer.Ingress {
+		if ingress.IP != "" {
+			return ingress.IP
+		}
+		if ingress.Hostname != "" {
+			return ingress.Hostname
+		}
+	}
+	return ""
+}
+
+func annotateGatewayNodes(c client.Client, loadBalancer bool) error {
+	nodes := &v1.NodeList{}
+	if err := c.List(context.TODO(), nodes, client.MatchingLabels{gatewayLabel: "true"}); err != nil {
+		return err
+	}
+	for i := range nodes.Items {
+		node := &nodes.Items[i]
+		annotated := node.Annotations[publicIPAnnotation] == loadBalancerPublicIP
+		if annotated == loadBalancer {
+			continue
+		}
+		patch := client.MergeFrom(node.DeepCopy())
+		if loadBalancer {
+			if node.Annotations == nil {
+				node.Annotations = map[string]string{}
+			}
+			node.Annotations[publicIPAnnotation] = loadBalancerPublicIP
+		} else {
+			delete(node.Annotations, publicIPAnnotation)
+		}
+		klog.Infof("Updating the public IP resolver of gateway node %s", node.GetName())
+		if err := c.Patch(context.TODO(), node, patch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gateway
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	operatorv1alpha2 "github.com/DanielXLee/cluster-fabric-operator/api/v1alpha2"
+)
+
+const testNamespace = "submariner-operator"
+
+var _ = Describe("Gateway load balancer", func() {
+	var c client.Client
+	var instance *operatorv1alpha2.Fabric
+
+	BeforeEach(func() {
+		c = fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(
+			&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "gateway", Labels: map[string]string{gatewayLabel: "true"}}},
+			&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "worker"}},
+		).Build()
+		instance = &operatorv1alpha2.Fabric{ObjectMeta: metav1.ObjectMeta{Name: "fabric", Namespace: "default"}}
+		instance.Spec.JoinConfig.NattPort = 4500
+	})
+
+	getNode := func(name string) *v1.Node {
+		node := &v1.Node{}
+		Expect(c.Get(context.TODO(), types.NamespacedName{Name: name}, node)).To(Succeed())
+		return node
+	}
+
+	When("Enabling the load balancer", func() {
+		It("Should expose the gateway ports and resolve the gateway public IP from it", func() {
+			svc, err := EnsureLoadBalancer(c, instance, testNamespace)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(svc.Spec.Type).To(Equal(v1.ServiceTypeLoadBalancer))
+			Expect(svc.Spec.Selector).To(HaveKeyWithValue("app", "submariner-gateway"))
+			Expect(svc.Spec.Ports).To(HaveLen(2))
+			Expect(svc.Spec.Ports[0].Port).To(BeEquivalentTo(4500))
+			Expect(LoadBalancerAddress(svc)).To(BeEmpty())
+
+			Expect(getNode("gateway").Annotations).To(HaveKeyWithValue(publicIPAnnotation, "lb:"+LoadBalancerName))
+			Expect(getNode("worker").Annotations).NotTo(HaveKey(publicIPAnnotation))
+		})
+	})
+
+	When("Disabling the load balancer", func() {
+		It("Should delete it and leave the gateway public IP alone", func() {
+			_, err := EnsureLoadBalancer(c, instance, testNamespace)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(DeleteLoadBalancer(c, testNamespace)).To(Succeed())
+
+			svc := &v1.Service{}
+			err = c.Get(context.TODO(), types.NamespacedName{Name: LoadBalancerName, Namespace: testNamespace}, svc)
+			Expect(err).To(HaveOccurred())
+			Expect(getNode("gateway").Annotations).NotTo(HaveKey(publicIPAnnotation))
+			Expect(DeleteLoadBalancer(c, testNamespace)).To(Succeed())
+		})
+	})
+
+	When("The cloud assigned the load balancer", func() {
+		It("Should report its address", func() {
+			svc := &v1.Service{}
+			svc.Status.LoadBalancer.Ingress = []v1.LoadBalancerIngress{{Hostname: "gw.elb.example.com"}}
+			Expect(LoadBalancerAddress(svc)).To(Equal("gw.elb.example.com"))
+		})
+	})
+})
@@ -20,6 +20,8 @@ import (
 	"context"
 
 	submariner "github.com/submariner-io/submariner-operator/apis/submariner/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/klog"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -52,3 +54,15 @@ func Ensure(c client.Client, brokerSpec submariner.BrokerSpec) error {
 	klog.Infof("Broker %s %s", brokerCR.GetName(), or)
 	return nil
 }
+
+// Delete removes the Broker CR
+func Delete(c client.Client) error {
+	brokerCR := &submariner.Broker{ObjectMeta: metav1.ObjectMeta{Name: consts.SubmarinerBrokerName, Namespace: consts.SubmarinerOperatorNamespace}}
+	// Without the Submariner CRDs, there is no broker left to delete
+	if err := c.Delete(context.TODO(), brokerCR); err != nil && !errors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+		klog.Errorf("Failed to delete Broker %s: %v", brokerCR.GetName(), err)
+		return err
+	}
+	klog.Infof("Broker %s deleted", brokerCR.GetName())
+	return nil
+}
@@ -23,6 +23,7 @@ import (
 
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/klog/v2"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -49,6 +50,18 @@ func Ensure(c client.Client, namespace, operatorName, image string, debug bool)
 	return deployments.WaitForReady(c, namespace, deployment.Name, deploymentCheckInterval, deploymentWaitTime)
 }
 
+// Delete removes the operator deployment
+func Delete(c client.Client, namespace, operatorName string) error {
+	deployment := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: operatorName, Namespace: namespace}}
+	fg := metav1.DeletePropagationForeground
+	if err := c.Delete(context.TODO(), deployment, &client.DeleteOptions{PropagationPolicy: &fg}); err != nil && !errors.IsNotFound(err) {
+		klog.Errorf("Failed to delete Deployment %s: %v", deployment.GetName(), err)
+		return err
+	}
+	klog.Infof("Deployment %s deleted", deployment.GetName())
+	return nil
+}
+
 func NewDeployment(deployment *appsv1.Deployment, namespace, operatorName, image string, debug bool) error {
 	replicas := int32(1)
 	imagePullPolicy := v1.PullAlways
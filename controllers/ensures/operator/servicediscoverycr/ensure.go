@@ -18,8 +18,13 @@ package servicediscoverycr
 
 import (
 	"context"
+	"time"
 
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/klog"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -29,6 +34,11 @@ import (
 	"github.com/DanielXLee/cluster-fabric-operator/controllers/ensures/names"
 )
 
+const (
+	deleteCheckInterval = 2 * time.Second
+	deleteWaitTime      = 5 * time.Minute
+)
+
 func Ensure(c client.Client, namespace string, serviceDiscoverySpec *submariner.ServiceDiscoverySpec) error {
 	sd := &submariner.ServiceDiscovery{ObjectMeta: metav1.ObjectMeta{Name: names.ServiceDiscoveryCrName, Namespace: namespace}}
 	or, err := ctrl.CreateOrUpdate(context.TODO(), c, sd, func() error {
@@ -42,3 +52,28 @@ func Ensure(c client.Client, namespace string, serviceDiscoverySpec *submariner.
 	klog.Infof("ServiceDiscovery %s %s", sd.GetName(), or)
 	return nil
 }
+
+// Delete removes the ServiceDiscovery CR and waits until it is gone
+func Delete(c client.Client, namespace string) error {
+	sd := &submariner.ServiceDiscovery{}
+	sdKey := types.NamespacedName{Name: names.ServiceDiscoveryCrName, Namespace: namespace}
+	return wait.PollImmediate(deleteCheckInterval, deleteWaitTime, func() (bool, error) {
+		if err := c.Get(context.TODO(), sdKey, sd); err != nil {
+			// Without the Submariner CRDs, there is no service discovery left to delete
+			if errors.IsNotFound(err) || meta.IsNoMatchError(err) {
+				return true, nil
+			}
+			return false, err
+		}
+		if sd.ObjectMeta.DeletionTimestamp.IsZero() {
+			klog.Infof("Deleting ServiceDiscovery %s", sd.GetName())
+			if err := c.Delete(context.TODO(), sd); err != nil {
+				if meta.IsNoMatchError(err) {
+					return true, nil
+				}
+				return false, client.IgnoreNotFound(err)
+			}
+		}
+		return false, nil
+	})
+}
@@ -20,7 +20,9 @@ import (
 	"context"
 	"time"
 
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -68,6 +70,11 @@ func Ensure(c client.Client, namespace string, submarinerSpec *submariner.Submar
 			return false, nil
 		}
 
+		if equality.Semantic.DeepEqual(submarinerCR.Spec, *submarinerSpec) {
+			klog.Info("SubmerinerCR is up to date")
+			return true, nil
+		}
+
 		klog.Info("Try to delete existing submerinerCR")
 		fg := metav1.DeletePropagationForeground
 		delOpts := &client.DeleteOptions{PropagationPolicy: &fg}
@@ -75,3 +82,32 @@ func Ensure(c client.Client, namespace string, submarinerSpec *submariner.Submar
 		return false, client.IgnoreNotFound(err)
 	})
 }
+
+// Delete removes the Submariner CR and waits until the submariner operator has finished its cleanup
+func Delete(c client.Client, namespace string) error {
+	submarinerCR := &submariner.Submariner{}
+	submarinerCRKey := types.NamespacedName{Name: SubmarinerName, Namespace: namespace}
+	return wait.ExponentialBackoff(backOff, func() (bool, error) {
+		if err := c.Get(context.TODO(), submarinerCRKey, submarinerCR); err != nil {
+			// Without the Submariner CRDs, there is no Submariner left to delete
+			if errors.IsNotFound(err) || meta.IsNoMatchError(err) {
+				return true, nil
+			}
+			return false, err
+		}
+
+		if !submarinerCR.ObjectMeta.DeletionTimestamp.IsZero() {
+			klog.Info("SubmerinerCR is deleted, waiting for the delete complete...")
+			return false, nil
+		}
+
+		klog.Info("Deleting submerinerCR")
+		fg := metav1.DeletePropagationForeground
+		delOpts := &client.DeleteOptions{PropagationPolicy: &fg}
+		err := c.Delete(context.TODO(), submarinerCR, delOpts)
+		if meta.IsNoMatchError(err) {
+			return true, nil
+		}
+		return false, client.IgnoreNotFound(err)
+	})
+}
@@ -40,3 +40,17 @@ func Ensure(c client.Client) error {
 	}
 	return nil
 }
+
+// Delete removes the operator CRDs from the cluster
+func Delete(c client.Client) error {
+	if err := utils.DeleteEmbeddedCRD(c, embeddedyamls.Manifests_deploy_crds_submariner_io_submariners_yaml); err != nil {
+		return err
+	}
+	if err := utils.DeleteEmbeddedCRD(c, embeddedyamls.Manifests_deploy_crds_submariner_io_servicediscoveries_yaml); err != nil {
+		return err
+	}
+	if err := utils.DeleteEmbeddedCRD(c, embeddedyamls.Manifests_deploy_crds_submariner_io_brokers_yaml); err != nil {
+		return err
+	}
+	return nil
+}
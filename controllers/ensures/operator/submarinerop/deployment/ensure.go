@@ -27,3 +27,8 @@ import (
 func Ensure(c client.Client, namespace, image string, debug bool) error {
 	return operatorpod.Ensure(c, namespace, names.OperatorComponent, image, debug)
 }
+
+// Delete removes the operator deployment
+func Delete(c client.Client, namespace string) error {
+	return operatorpod.Delete(c, namespace, names.OperatorComponent)
+}
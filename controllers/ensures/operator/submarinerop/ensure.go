@@ -58,3 +58,17 @@ func Ensure(c client.Client, config *rest.Config, debug bool) error {
 	klog.Info("Deployed the operator successfully")
 	return nil
 }
+
+// Uninstall removes the operator deployment and the operator CRDs
+func Uninstall(c client.Client) error {
+	if err := deployment.Delete(c, consts.SubmarinerOperatorNamespace); err != nil {
+		return err
+	}
+	klog.Info("Deleted the operator deployment")
+
+	if err := crds.Delete(c); err != nil {
+		return err
+	}
+	klog.Info("Deleted operator CRDs")
+	return nil
+}
@@ -22,6 +22,7 @@ import (
 
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/rest"
 	"k8s.io/klog"
@@ -62,3 +63,17 @@ func CreateOrUpdateEmbeddedCRD(c client.Client, crdYaml string) error {
 	klog.Infof("CRD %s %s", crd.GetName(), or)
 	return nil
 }
+
+func DeleteEmbeddedCRD(c client.Client, crdYaml string) error {
+	crdName, err := embeddedyamls.GetObjectName(crdYaml)
+	if err != nil {
+		return err
+	}
+	crd := &apiextensionsv1.CustomResourceDefinition{ObjectMeta: metav1.ObjectMeta{Name: crdName}}
+	if err := c.Delete(context.TODO(), crd); err != nil && !errors.IsNotFound(err) {
+		klog.Errorf("Failed to delete CRD %s: %v", crd.GetName(), err)
+		return err
+	}
+	klog.Infof("CRD %s deleted", crd.GetName())
+	return nil
+}
@@ -17,10 +17,15 @@ limitations under the License.
 package controllers
 
 import (
+	"bytes"
 	"context"
+	"fmt"
 	"reflect"
+	"time"
 
+	submarinerv1 "github.com/submariner-io/submariner/pkg/apis/submariner.io/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -29,30 +34,56 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
-	operatorv1alpha1 "github.com/DanielXLee/cluster-fabric-operator/api/v1alpha1"
+	operatorv1alpha2 "github.com/DanielXLee/cluster-fabric-operator/api/v1alpha2"
 	consts "github.com/DanielXLee/cluster-fabric-operator/controllers/ensures"
 	"github.com/DanielXLee/cluster-fabric-operator/controllers/ensures/broker"
+	"github.com/DanielXLee/cluster-fabric-operator/controllers/ensures/gateway"
 )
 
+// resyncPeriod is how often a fabric is reconciled without a change: a joined cluster re-reads the broker info,
+// to pick up a rotated IPsec PSK, and the broker refreshes the status of the joined clusters
+const resyncPeriod = 5 * time.Minute
+
 // FabricReconciler reconciles a Fabric object
 type FabricReconciler struct {
 	client.Client
 	client.Reader
 	*rest.Config
-	Scheme       *runtime.Scheme
+	Scheme *runtime.Scheme
+
+	// DeployBroker and JoinBroker restrict the roles the fabrics may request, every role is permitted when both are
+	// unset. On the reconciler of a fabric, they are the roles performed for the fabric.
 	DeployBroker bool
 	JoinBroker   bool
+
+	// MigrateFabrics hands the fabrics over to brokers and cluster joins instead of reconciling them
+	MigrateFabrics bool
+
+	// brokerInfo is preset on the reconcilers bound to the managed clusters in hub mode,
+	// which have no broker info of their own
+	brokerInfo *broker.BrokerInfo
+
+	// managedCluster is set on the reconcilers bound to the managed clusters in hub mode
+	managedCluster bool
+
+	// sharedOperator is set when the submariner operator of the cluster is also used by a broker or a cluster
+	// join other than the one reconciled, the operator is then left in place on uninstall
+	sharedOperator bool
 }
 
 //+kubebuilder:rbac:groups=operator.tkestack.io,resources=fabrics,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=operator.tkestack.io,resources=fabrics/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=operator.tkestack.io,resources=fabrics/finalizers,verbs=update
+//+kubebuilder:rbac:groups=operator.tkestack.io,resources=globalcidrallocations,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=operator.tkestack.io,resources=globalcidrallocations/status,verbs=get;update;patch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -65,7 +96,7 @@ type FabricReconciler struct {
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.8.3/pkg/reconcile
 func (r *FabricReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, err error) {
 	klog.Infof("Start reconciling Fabric: %s", req.NamespacedName)
-	instance := &operatorv1alpha1.Fabric{}
+	instance := &operatorv1alpha2.Fabric{}
 
 	if err := r.Client.Get(context.TODO(), req.NamespacedName, instance); err != nil {
 		if errors.IsNotFound(err) {
@@ -74,13 +105,52 @@ func (r *FabricReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ c
 		return ctrl.Result{}, err
 	}
 
+	// The migrated fabrics are reconciled as brokers and cluster joins, nothing is left to do
+	if _, ok := instance.GetAnnotations()[consts.FabricMigratedAnnotation]; ok {
+		klog.Infof("Fabric %s is migrated, skip it", req.NamespacedName)
+		return ctrl.Result{}, nil
+	}
+
+	// The roles are decided per fabric, out of the ones the operator is permitted to perform
+	roleReconciler, rolesErr := r.newRoleReconciler(instance)
+
+	// Uninstall submariner when the fabric is being deleted
+	if !instance.ObjectMeta.DeletionTimestamp.IsZero() {
+		klog.Infof("Fabric %s is being deleted", req.NamespacedName)
+		return ctrl.Result{}, roleReconciler.FinalizeFabric(instance)
+	}
+
+	if r.MigrateFabrics {
+		if rolesErr != nil {
+			// A fabric requesting a role the operator is not permitted to perform is not handed over, it keeps its
+			// finalizer so deleting it still uninstalls what it deployed
+			return ctrl.Result{}, r.markMigrationFailed(ctx, instance, rolesErr)
+		}
+		return ctrl.Result{}, roleReconciler.MigrateFabric(instance)
+	}
+
+	if !controllerutil.ContainsFinalizer(instance, consts.FabricFinalizer) {
+		controllerutil.AddFinalizer(instance, consts.FabricFinalizer)
+		if err := r.Client.Update(ctx, instance); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
 	originalInstance := instance.DeepCopy()
 	// Always attempt to patch the status after each reconciliation.
 	defer func() {
-		if err != nil {
-			instance.Status.Phase = operatorv1alpha1.PhaseFailed
+		instance.Status.ObservedGeneration = instance.Generation
+		failure := err
+		if failure == nil {
+			// The denied roles are not retried, the permitted roles only change along with the operator flags
+			failure = rolesErr
+		}
+		if failure != nil {
+			instance.Status.Phase = operatorv1alpha2.PhaseFailed
+			instance.Status.Message = failure.Error()
 		} else {
-			instance.Status.Phase = operatorv1alpha1.PhaseRunning
+			instance.Status.Phase = operatorv1alpha2.PhaseRunning
+			instance.Status.Message = ""
 		}
 		if reflect.DeepEqual(originalInstance.Status, instance.Status) {
 			return
@@ -90,31 +160,108 @@ func (r *FabricReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ c
 		}
 	}()
 
+	markRolePermitted(instance, rolesErr)
+	if err := roleReconciler.reconcileFabric(instance); err != nil {
+		return ctrl.Result{}, err
+	}
+	klog.Infof("Finished reconciling Fabric: %s", req.NamespacedName)
+	return ctrl.Result{RequeueAfter: resyncPeriod}, nil
+}
+
+// reconcileFabric deploys the broker and joins the cluster to the broker, as the roles of the reconciler say
+func (r *FabricReconciler) reconcileFabric(instance *operatorv1alpha2.Fabric) error {
 	// Deploy submeriner broker
 	if r.DeployBroker {
 		klog.Info("Deploy submeriner broker")
 		if err := r.DeploySubmerinerBroker(instance); err != nil {
-			return ctrl.Result{}, err
+			return err
+		}
+
+		joinedClusters, err := broker.ListJoinedClusters(r.Reader, consts.SubmarinerBrokerNamespace)
+		if err != nil {
+			klog.Errorf("Unable to list the joined clusters: %v", err)
+			return err
+		}
+		instance.Status.JoinedClusters = joinedClusters
+
+		if err := r.UpdateGlobalnetCapacity(instance); err != nil {
+			klog.Errorf("Unable to compute the globalnet capacity: %v", err)
+			return err
+		}
+
+		if len(instance.Spec.ManagedClusters) > 0 || len(instance.Status.ManagedClusters) > 0 {
+			klog.Info("Join managed clusters to submeriner broker")
+			if err := r.JoinManagedClusters(instance); err != nil {
+				return err
+			}
 		}
 	}
 
 	// Join managed cluster to submeriner borker
 	if r.JoinBroker {
 		klog.Info("Join managed cluster to submeriner broker")
-		brokerInfo, err := broker.NewFromConfigMap(r.Client)
+		brokerInfo, err := r.GetBrokerInfo(instance)
 		if err != nil {
-			return ctrl.Result{}, err
+			markStageFailed(instance, operatorv1alpha2.ConditionBrokerReady, operatorv1alpha2.ReasonFailed,
+				fmt.Errorf("unable to read the broker info: %v", err))
+			return err
 		}
 		if err := r.JoinSubmarinerCluster(instance, brokerInfo); err != nil {
-			return ctrl.Result{}, err
+			return err
 		}
 	}
-	klog.Infof("Finished reconciling Fabric: %s", req.NamespacedName)
-	return ctrl.Result{}, nil
+	return nil
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *FabricReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	b := watchLabeledResources(ctrl.NewControllerManagedBy(mgr).For(&operatorv1alpha2.Fabric{}))
+	mapToAllFabrics := handler.EnqueueRequestsFromMapFunc(func(obj client.Object) []reconcile.Request {
+		fabrics := &operatorv1alpha2.FabricList{}
+		if err := r.Client.List(context.TODO(), fabrics); err != nil {
+			klog.Errorf("List fabrics failed: %v", err)
+			return nil
+		}
+		requests := make([]reconcile.Request, 0, len(fabrics.Items))
+		for _, fabric := range fabrics.Items {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+				Name:      fabric.GetName(),
+				Namespace: fabric.GetNamespace(),
+			}})
+		}
+		return requests
+	})
+	if r.joinPermitted() {
+		b = watchIPSecPSK(b, mapToAllFabrics)
+	}
+	if r.brokerPermitted() {
+		// Refresh the joined clusters of the broker fabrics when a cluster joins, leaves, or updates its gateways
+		var err error
+		if b, err = watchJoinedClusters(mgr, r.Scheme, b, mapToAllFabrics); err != nil {
+			return err
+		}
+	}
+	return b.Complete(r)
+}
+
+// watchLabeledResources watches the resources labeled with the name and namespace of the object they are created
+// for: the object of the same name and namespace is reconciled when they are deleted, or when the gateway load
+// balancer is assigned an address
+func watchLabeledResources(b *builder.Builder) *builder.Builder {
+	mapToFabric := handler.EnqueueRequestsFromMapFunc(func(obj client.Object) []reconcile.Request {
+		lables := obj.GetLabels()
+		name, nameOk := lables[consts.FabricNameLabel]
+		ns, namespaceOK := lables[consts.FabricNamespaceLabel]
+		if nameOk && namespaceOK {
+			return []reconcile.Request{
+				{NamespacedName: types.NamespacedName{
+					Name:      name,
+					Namespace: ns,
+				}},
+			}
+		}
+		return nil
+	})
 	cmPredicates := predicate.Funcs{
 		CreateFunc: func(e event.CreateEvent) bool {
 			return false
@@ -132,25 +279,89 @@ func (r *FabricReconciler) SetupWithManager(mgr ctrl.Manager) error {
 			return false
 		},
 	}
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&operatorv1alpha1.Fabric{}).
+	// Track the external address of the gateway load balancer as the cloud assigns it
+	svcPredicates := predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return false
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldSvc, oldOk := e.ObjectOld.(*corev1.Service)
+			newSvc, newOk := e.ObjectNew.(*corev1.Service)
+			if !oldOk || !newOk || newSvc.GetName() != gateway.LoadBalancerName {
+				return false
+			}
+			return !equality.Semantic.DeepEqual(oldSvc.Status.LoadBalancer, newSvc.Status.LoadBalancer)
+		},
+		DeleteFunc: cmPredicates.DeleteFunc,
+		GenericFunc: func(e event.GenericEvent) bool {
+			return false
+		},
+	}
+	return b.
 		Watches(
 			&source.Kind{Type: &corev1.ConfigMap{}},
-			handler.EnqueueRequestsFromMapFunc(func(obj client.Object) []reconcile.Request {
-				lables := obj.GetLabels()
-				name, nameOk := lables[consts.FabricNameLabel]
-				ns, namespaceOK := lables[consts.FabricNamespaceLabel]
-				if nameOk && namespaceOK {
-					return []reconcile.Request{
-						{NamespacedName: types.NamespacedName{
-							Name:      name,
-							Namespace: ns,
-						}},
-					}
-				}
-				return nil
-			}),
+			mapToFabric,
 			builder.WithPredicates(cmPredicates),
 		).
-		Complete(r)
+		Watches(
+			&source.Kind{Type: &corev1.Secret{}},
+			mapToFabric,
+			builder.WithPredicates(cmPredicates),
+		).
+		Watches(
+			&source.Kind{Type: &corev1.Service{}},
+			mapToFabric,
+			builder.WithPredicates(svcPredicates),
+		)
+}
+
+// watchIPSecPSK reconciles the objects joining a cluster when the broker deployed on this cluster rotates its
+// IPsec PSK, the clusters joined from this cluster then pick the new PSK up without waiting for their resync
+func watchIPSecPSK(b *builder.Builder, mapToAll handler.EventHandler) *builder.Builder {
+	pskPredicates := predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return false
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldSecret, oldOk := e.ObjectOld.(*corev1.Secret)
+			newSecret, newOk := e.ObjectNew.(*corev1.Secret)
+			if !oldOk || !newOk || newSecret.GetName() != broker.IPSecPSKSecretName ||
+				newSecret.GetNamespace() != consts.SubmarinerBrokerNamespace {
+				return false
+			}
+			return !bytes.Equal(oldSecret.Data["psk"], newSecret.Data["psk"])
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return false
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return false
+		},
+	}
+	return b.Watches(&source.Kind{Type: &corev1.Secret{}}, mapToAll, builder.WithPredicates(pskPredicates))
+}
+
+// watchJoinedClusters watches the service accounts, clusters and endpoints of the clusters joined to the broker
+func watchJoinedClusters(mgr ctrl.Manager, scheme *runtime.Scheme, b *builder.Builder, mapToAll handler.EventHandler) (*builder.Builder, error) {
+	brokerPredicates := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return obj.GetNamespace() == consts.SubmarinerBrokerNamespace
+	})
+	b = b.Watches(
+		&source.Kind{Type: &corev1.ServiceAccount{}},
+		mapToAll,
+		builder.WithPredicates(brokerPredicates),
+	)
+	// The submariner CRDs are installed along with the broker, until then the periodic resync covers them
+	for _, obj := range []client.Object{&submarinerv1.Cluster{}, &submarinerv1.Endpoint{}} {
+		gvk, err := apiutil.GVKForObject(obj, scheme)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := mgr.GetRESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version); err != nil {
+			klog.Infof("%s not installed yet, not watching it", gvk.Kind)
+			continue
+		}
+		b = b.Watches(&source.Kind{Type: obj}, mapToAll, builder.WithPredicates(brokerPredicates))
+	}
+	return b, nil
 }
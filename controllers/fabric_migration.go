@@ -0,0 +1,120 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	operatorv1alpha2 "github.com/DanielXLee/cluster-fabric-operator/api/v1alpha2"
+	consts "github.com/DanielXLee/cluster-fabric-operator/controllers/ensures"
+)
+
+// MigrateFabric hands the fabric over to a broker and a cluster join of the same name, created for the roles the
+// fabric requests and the operator is permitted to perform. The fabric is kept, annotated as migrated and without
+// its finalizer, so deleting it no longer uninstalls what the broker and the cluster join now own.
+func (r *FabricReconciler) MigrateFabric(instance *operatorv1alpha2.Fabric) error {
+	if r.DeployBroker {
+		klog.Infof("Migrate fabric %s/%s to a broker", instance.GetNamespace(), instance.GetName())
+		if err := r.createIfNotFound(newBrokerFromFabric(instance)); err != nil {
+			return err
+		}
+	}
+	if r.JoinBroker {
+		klog.Infof("Migrate fabric %s/%s to a cluster join", instance.GetNamespace(), instance.GetName())
+		if err := r.createIfNotFound(newClusterJoinFromFabric(instance)); err != nil {
+			return err
+		}
+	}
+
+	annotations := instance.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[consts.FabricMigratedAnnotation] = "true"
+	instance.SetAnnotations(annotations)
+	controllerutil.RemoveFinalizer(instance, consts.FabricFinalizer)
+	return r.Client.Update(context.TODO(), instance)
+}
+
+// markMigrationFailed reports in the fabric status the roles which prevent its migration, as the reconciliation
+// of the fabric does
+func (r *FabricReconciler) markMigrationFailed(ctx context.Context, instance *operatorv1alpha2.Fabric, rolesErr error) error {
+	originalStatus := instance.Status.DeepCopy()
+	instance.Status.ObservedGeneration = instance.Generation
+	markRolePermitted(instance, rolesErr)
+	instance.Status.Phase = operatorv1alpha2.PhaseFailed
+	instance.Status.Message = rolesErr.Error()
+	if reflect.DeepEqual(*originalStatus, instance.Status) {
+		return nil
+	}
+	return r.Status().Update(ctx, instance)
+}
+
+// createIfNotFound keeps an object which already exists, it may have been created or edited since
+func (r *FabricReconciler) createIfNotFound(obj client.Object) error {
+	err := r.Client.Create(context.TODO(), obj)
+	if errors.IsAlreadyExists(err) {
+		klog.Infof("%s/%s already exists, keep it", obj.GetNamespace(), obj.GetName())
+		return nil
+	}
+	return err
+}
+
+// newBrokerFromFabric returns the broker deploying the broker of the fabric, along with its managed clusters
+func newBrokerFromFabric(instance *operatorv1alpha2.Fabric) *operatorv1alpha2.Broker {
+	brokerInstance := &operatorv1alpha2.Broker{
+		ObjectMeta: newMigratedObjectMeta(instance),
+	}
+	instance.Spec.GetBrokerConfig().DeepCopyInto(&brokerInstance.Spec.BrokerConfig)
+	for i := range instance.Spec.ManagedClusters {
+		brokerInstance.Spec.ManagedClusters = append(brokerInstance.Spec.ManagedClusters, *instance.Spec.ManagedClusters[i].DeepCopy())
+	}
+	return brokerInstance
+}
+
+// newClusterJoinFromFabric returns the cluster join joining the cluster of the fabric
+func newClusterJoinFromFabric(instance *operatorv1alpha2.Fabric) *operatorv1alpha2.ClusterJoin {
+	clusterJoin := &operatorv1alpha2.ClusterJoin{
+		ObjectMeta: newMigratedObjectMeta(instance),
+	}
+	instance.Spec.JoinConfig.DeepCopyInto(&clusterJoin.Spec.JoinConfig)
+	instance.Spec.CloudPrepareConfig.DeepCopyInto(&clusterJoin.Spec.CloudPrepareConfig)
+	return clusterJoin
+}
+
+// newMigratedObjectMeta keeps the name and the namespace of the fabric, the resources labeled with them are then
+// tracked by the objects the fabric is migrated to
+func newMigratedObjectMeta(instance *operatorv1alpha2.Fabric) metav1.ObjectMeta {
+	objectMeta := metav1.ObjectMeta{
+		Name:      instance.GetName(),
+		Namespace: instance.GetNamespace(),
+	}
+	if labels := instance.GetLabels(); labels != nil {
+		objectMeta.Labels = map[string]string{}
+		for key, value := range labels {
+			objectMeta.Labels[key] = value
+		}
+	}
+	return objectMeta
+}
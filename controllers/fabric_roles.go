@@ -0,0 +1,81 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/klog/v2"
+
+	operatorv1alpha2 "github.com/DanielXLee/cluster-fabric-operator/api/v1alpha2"
+)
+
+// brokerPermitted reports whether the operator may deploy the broker, every role is permitted when the operator is
+// restricted to none
+func (r *FabricReconciler) brokerPermitted() bool {
+	return r.DeployBroker || !r.JoinBroker
+}
+
+// joinPermitted reports whether the operator may join its cluster to the broker
+func (r *FabricReconciler) joinPermitted() bool {
+	return r.JoinBroker || !r.DeployBroker
+}
+
+// newRoleReconciler returns the reconciler performing the roles the fabric requests and the operator is permitted
+// to perform, along with an error naming the requested roles the operator is not permitted to perform. A fabric
+// requesting no role, such as one missing its cluster ID, is reported rather than taken for a broker.
+func (r *FabricReconciler) newRoleReconciler(instance *operatorv1alpha2.Fabric) (*FabricReconciler, error) {
+	deployBroker, joinBroker := instance.Spec.RequestedRoles()
+	roleReconciler, err := r.newPermittedReconciler(deployBroker, joinBroker)
+	if !deployBroker && !joinBroker {
+		return roleReconciler, fmt.Errorf("the fabric requests no role, set spec.roles, spec.brokerConfig or spec.joinConfig.clusterID")
+	}
+	return roleReconciler, err
+}
+
+// newPermittedReconciler returns the reconciler performing the given roles the operator is permitted to perform,
+// along with an error naming the given roles the operator is not permitted to perform
+func (r *FabricReconciler) newPermittedReconciler(deployBroker, joinBroker bool) (*FabricReconciler, error) {
+	var denied []string
+	if deployBroker && !r.brokerPermitted() {
+		denied = append(denied, "deploy the broker (--deploy-broker)")
+		deployBroker = false
+	}
+	if joinBroker && !r.joinPermitted() {
+		denied = append(denied, "join the broker (--join-broker)")
+		joinBroker = false
+	}
+
+	roleReconciler := *r
+	roleReconciler.DeployBroker = deployBroker
+	roleReconciler.JoinBroker = joinBroker
+	if len(denied) > 0 {
+		return &roleReconciler, fmt.Errorf("the operator is not permitted to %s", strings.Join(denied, " or "))
+	}
+	return &roleReconciler, nil
+}
+
+// markRolePermitted reports in the fabric status whether the operator is permitted to perform the roles requested
+func markRolePermitted(instance *operatorv1alpha2.Fabric, rolesErr error) {
+	if rolesErr != nil {
+		klog.Warningf("%s/%s: %v", instance.GetNamespace(), instance.GetName(), rolesErr)
+		markStageFailed(instance, operatorv1alpha2.ConditionRolePermitted, operatorv1alpha2.ReasonRoleNotPermitted, rolesErr)
+	} else {
+		markStageSucceeded(instance, operatorv1alpha2.ConditionRolePermitted, "")
+	}
+}
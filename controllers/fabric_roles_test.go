@@ -0,0 +1,130 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	operatorv1alpha2 "github.com/DanielXLee/cluster-fabric-operator/api/v1alpha2"
+	consts "github.com/DanielXLee/cluster-fabric-operator/controllers/ensures"
+)
+
+var _ = Describe("Fabric roles", func() {
+	joinConfig := operatorv1alpha2.JoinConfig{ClusterID: "cluster1"}
+
+	It("Should only join a fabric without a broker configuration", func() {
+		fabric := &operatorv1alpha2.Fabric{Spec: operatorv1alpha2.FabricSpec{JoinConfig: joinConfig}}
+		roleReconciler, err := (&FabricReconciler{}).newRoleReconciler(fabric)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(roleReconciler.DeployBroker).To(BeFalse())
+		Expect(roleReconciler.JoinBroker).To(BeTrue())
+	})
+
+	It("Should deploy the broker of a fabric with a broker configuration", func() {
+		fabric := &operatorv1alpha2.Fabric{Spec: operatorv1alpha2.FabricSpec{
+			BrokerConfig: &operatorv1alpha2.BrokerConfig{},
+			JoinConfig:   joinConfig,
+		}}
+		roleReconciler, err := (&FabricReconciler{}).newRoleReconciler(fabric)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(roleReconciler.DeployBroker).To(BeTrue())
+		Expect(roleReconciler.JoinBroker).To(BeTrue())
+	})
+
+	It("Should perform the roles the fabric states over the sections of its spec", func() {
+		fabric := &operatorv1alpha2.Fabric{Spec: operatorv1alpha2.FabricSpec{
+			Roles:        []operatorv1alpha2.FabricRole{operatorv1alpha2.FabricRoleJoin},
+			BrokerConfig: &operatorv1alpha2.BrokerConfig{},
+			JoinConfig:   joinConfig,
+		}}
+		roleReconciler, err := (&FabricReconciler{}).newRoleReconciler(fabric)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(roleReconciler.DeployBroker).To(BeFalse())
+		Expect(roleReconciler.JoinBroker).To(BeTrue())
+	})
+
+	It("Should not deploy the broker of a fabric missing its cluster ID", func() {
+		roleReconciler, err := (&FabricReconciler{}).newRoleReconciler(&operatorv1alpha2.Fabric{})
+		Expect(err).To(MatchError(ContainSubstring("requests no role")))
+		Expect(roleReconciler.DeployBroker).To(BeFalse())
+		Expect(roleReconciler.JoinBroker).To(BeFalse())
+	})
+
+	It("Should deny the roles the operator is not permitted to perform", func() {
+		fabric := &operatorv1alpha2.Fabric{Spec: operatorv1alpha2.FabricSpec{
+			BrokerConfig: &operatorv1alpha2.BrokerConfig{},
+			JoinConfig:   joinConfig,
+		}}
+		roleReconciler, err := (&FabricReconciler{JoinBroker: true}).newRoleReconciler(fabric)
+		Expect(err).To(MatchError(ContainSubstring("--deploy-broker")))
+		Expect(roleReconciler.DeployBroker).To(BeFalse())
+		Expect(roleReconciler.JoinBroker).To(BeTrue())
+
+		roleReconciler, err = (&FabricReconciler{}).newRoleReconciler(fabric)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(roleReconciler.DeployBroker).To(BeTrue())
+		Expect(roleReconciler.JoinBroker).To(BeTrue())
+	})
+
+	It("Should only perform the role of the broker and the cluster join objects", func() {
+		brokerReconciler, err := (&BrokerReconciler{JoinBroker: true}).newFabricReconciler()
+		Expect(err).To(HaveOccurred())
+		Expect(brokerReconciler.DeployBroker).To(BeFalse())
+		Expect(brokerReconciler.JoinBroker).To(BeFalse())
+
+		joinReconciler, err := (&ClusterJoinReconciler{}).newFabricReconciler()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(joinReconciler.DeployBroker).To(BeFalse())
+		Expect(joinReconciler.JoinBroker).To(BeTrue())
+	})
+
+	It("Should not migrate a fabric requesting a role the operator is not permitted to perform", func() {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(operatorv1alpha2.AddToScheme(scheme)).To(Succeed())
+		fabric := &operatorv1alpha2.Fabric{
+			ObjectMeta: metav1.ObjectMeta{Name: "fabric", Namespace: "default", Finalizers: []string{consts.FabricFinalizer}},
+			Spec: operatorv1alpha2.FabricSpec{
+				BrokerConfig: &operatorv1alpha2.BrokerConfig{},
+				JoinConfig:   joinConfig,
+			},
+		}
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(fabric).Build()
+		r := &FabricReconciler{Client: c, Reader: c, Scheme: scheme, JoinBroker: true, MigrateFabrics: true}
+
+		_, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(fabric)})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(c.Get(context.TODO(), client.ObjectKeyFromObject(fabric), fabric)).To(Succeed())
+		Expect(fabric.GetAnnotations()).NotTo(HaveKey(consts.FabricMigratedAnnotation))
+		Expect(fabric.GetFinalizers()).To(ContainElement(consts.FabricFinalizer))
+		Expect(fabric.Status.Phase).To(Equal(operatorv1alpha2.PhaseFailed))
+		Expect(fabric.Status.Message).To(ContainSubstring("--deploy-broker"))
+		err = c.Get(context.TODO(), client.ObjectKeyFromObject(fabric), &operatorv1alpha2.ClusterJoin{})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	})
+})
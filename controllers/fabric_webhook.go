@@ -0,0 +1,203 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	operatorv1alpha2 "github.com/DanielXLee/cluster-fabric-operator/api/v1alpha2"
+	"github.com/DanielXLee/cluster-fabric-operator/controllers/versions"
+)
+
+const (
+	defaultCableDriver = "libreswan"
+	defaultNattPort    = 4500
+	defaultIkePort     = 500
+
+	mutateFabricPath      = "/mutate-operator-tkestack-io-v1alpha2-fabric"
+	mutateBrokerPath      = "/mutate-operator-tkestack-io-v1alpha2-broker"
+	mutateClusterJoinPath = "/mutate-operator-tkestack-io-v1alpha2-clusterjoin"
+)
+
+//+kubebuilder:webhook:path=/mutate-operator-tkestack-io-v1alpha2-fabric,mutating=true,failurePolicy=fail,sideEffects=None,groups=operator.tkestack.io,resources=fabrics,verbs=create;update,versions=v1alpha2,name=mfabric.kb.io,admissionReviewVersions={v1,v1beta1}
+//+kubebuilder:webhook:path=/mutate-operator-tkestack-io-v1alpha2-broker,mutating=true,failurePolicy=fail,sideEffects=None,groups=operator.tkestack.io,resources=brokers,verbs=create;update,versions=v1alpha2,name=mbroker.kb.io,admissionReviewVersions={v1,v1beta1}
+//+kubebuilder:webhook:path=/mutate-operator-tkestack-io-v1alpha2-clusterjoin,mutating=true,failurePolicy=fail,sideEffects=None,groups=operator.tkestack.io,resources=clusterjoins,verbs=create;update,versions=v1alpha2,name=mclusterjoin.kb.io,admissionReviewVersions={v1,v1beta1}
+
+// FabricDefaulter stores the defaults of the join configurations in the fabrics, brokers and cluster joins, the
+// ones of the operator and the ones provided by the broker, so the deployed clusters don't change along with the
+// defaults of a newer operator
+type FabricDefaulter struct {
+	Client client.Client
+	Scheme *runtime.Scheme
+
+	decoder *admission.Decoder
+}
+
+var _ admission.Handler = &FabricDefaulter{}
+
+// SetupWebhookWithManager registers the defaulting webhooks with the webhook server of the manager
+func (d *FabricDefaulter) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	for _, path := range []string{mutateFabricPath, mutateBrokerPath, mutateClusterJoinPath} {
+		mgr.GetWebhookServer().Register(path, &webhook.Admission{Handler: d})
+	}
+	return nil
+}
+
+// InjectDecoder implements admission.DecoderInjector
+func (d *FabricDefaulter) InjectDecoder(decoder *admission.Decoder) error {
+	d.decoder = decoder
+	return nil
+}
+
+// Handle fills the unset fields of the join configurations of the object in, the response only patches the
+// defaulted fields so the rest of the object is stored as sent
+func (d *FabricDefaulter) Handle(ctx context.Context, req admission.Request) admission.Response {
+	obj := map[string]interface{}{}
+	if err := json.Unmarshal(req.Object.Raw, &obj); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	switch req.Kind.Kind {
+	case "Broker":
+		brokerInstance := &operatorv1alpha2.Broker{}
+		if err := d.decoder.Decode(req, brokerInstance); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		// The managed clusters join the broker deployed by this very broker
+		if err := setManagedClusterDefaults(obj, brokerInstance.Spec.ManagedClusters, brokerInstance.Spec.BrokerConfig.DefaultCustomDomains); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+	case "ClusterJoin":
+		clusterJoin := &operatorv1alpha2.ClusterJoin{}
+		if err := d.decoder.Decode(req, clusterJoin); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		defaults := d.clusterJoinConfigDefaults(newClusterJoinFabric(clusterJoin))
+		if err := setJoinConfigDefaults(obj, defaults, "spec", "joinConfig"); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+	default:
+		fabric := &operatorv1alpha2.Fabric{}
+		if err := d.decoder.Decode(req, fabric); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		// The fabrics only deploying the broker have no join configuration of their own
+		if fabric.Spec.JoinConfig.ClusterID != "" {
+			if err := setJoinConfigDefaults(obj, d.clusterJoinConfigDefaults(fabric), "spec", "joinConfig"); err != nil {
+				return admission.Errored(http.StatusBadRequest, err)
+			}
+		}
+		// The managed clusters join the broker deployed by this very fabric
+		if err := setManagedClusterDefaults(obj, fabric.Spec.ManagedClusters, fabric.Spec.GetBrokerConfig().DefaultCustomDomains); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+	}
+
+	marshaled, err := json.Marshal(obj)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.PatchResponseFromRaw(req.Object.Raw, marshaled)
+}
+
+// clusterJoinConfigDefaults returns the defaults of the join configuration of the cluster the fabric lives in,
+// with the custom domains of the broker it joins. The broker is only looked up while the custom domains are
+// unset, they are kept once stored
+func (d *FabricDefaulter) clusterJoinConfigDefaults(instance *operatorv1alpha2.Fabric) map[string]interface{} {
+	var customDomains []string
+	if instance.Spec.JoinConfig.CustomDomains == nil {
+		r := &FabricReconciler{Client: d.Client, Scheme: d.Scheme}
+		if brokerInfo, err := r.GetBrokerInfo(instance); err != nil {
+			// The custom domains are inherited on reconcile then, the broker may not be set up yet
+			klog.Warningf("Unable to read the broker info to default %s/%s: %v", instance.GetNamespace(), instance.GetName(), err)
+		} else if brokerInfo.CustomDomains != nil {
+			customDomains = *brokerInfo.CustomDomains
+		}
+	}
+	return joinConfigDefaults(&instance.Spec.JoinConfig, customDomains)
+}
+
+// joinConfigDefaults returns the defaults of the unset fields of the join configuration keyed by their json name,
+// what is already stored is kept
+func joinConfigDefaults(joinConfig *operatorv1alpha2.JoinConfig, customDomains []string) map[string]interface{} {
+	defaults := map[string]interface{}{}
+	if joinConfig.Repository == "" {
+		defaults["repository"] = versions.DefaultRepo
+	}
+	if joinConfig.ImageVersion == "" {
+		defaults["imageVersion"] = versions.DefaultSubmarinerOperatorVersion
+	}
+	if joinConfig.CableDriver == "" {
+		defaults["cableDriver"] = defaultCableDriver
+	}
+	if joinConfig.NattPort == 0 {
+		defaults["nattPort"] = int64(defaultNattPort)
+	}
+	if joinConfig.IkePort == 0 {
+		defaults["ikePort"] = int64(defaultIkePort)
+	}
+	if joinConfig.CustomDomains == nil && len(customDomains) > 0 {
+		domains := make([]interface{}, 0, len(customDomains))
+		for _, domain := range customDomains {
+			domains = append(domains, domain)
+		}
+		defaults["customDomains"] = domains
+	}
+	return defaults
+}
+
+// setJoinConfigDefaults stores the join configuration defaults in the raw object, under the join configuration
+// at fields
+func setJoinConfigDefaults(obj map[string]interface{}, defaults map[string]interface{}, fields ...string) error {
+	for name, value := range defaults {
+		if err := unstructured.SetNestedField(obj, value, append(append([]string{}, fields...), name)...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setManagedClusterDefaults stores the join configuration defaults of the managed clusters in the raw object
+func setManagedClusterDefaults(obj map[string]interface{}, managedClusters []operatorv1alpha2.ManagedCluster, customDomains []string) error {
+	rawClusters, found, err := unstructured.NestedSlice(obj, "spec", "managedClusters")
+	if err != nil || !found {
+		return err
+	}
+	for i := range managedClusters {
+		if i >= len(rawClusters) {
+			break
+		}
+		rawCluster, ok := rawClusters[i].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if err := setJoinConfigDefaults(rawCluster, joinConfigDefaults(&managedClusters[i].JoinConfig, customDomains), "joinConfig"); err != nil {
+			return err
+		}
+	}
+	return unstructured.SetNestedSlice(obj, rawClusters, "spec", "managedClusters")
+}
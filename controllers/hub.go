@@ -0,0 +1,157 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	operatorv1alpha2 "github.com/DanielXLee/cluster-fabric-operator/api/v1alpha2"
+	"github.com/DanielXLee/cluster-fabric-operator/controllers/ensures/broker"
+)
+
+// JoinManagedClusters joins every managed cluster listed in the fabric to the broker deployed on this cluster,
+// and records the outcome per cluster. A cluster failing to join does not hold back the others.
+// Clusters removed from the list are no longer reconciled, they stay joined until uninstalled.
+func (r *FabricReconciler) JoinManagedClusters(instance *operatorv1alpha2.Fabric) error {
+	var brokerInfo *broker.BrokerInfo
+	if len(instance.Spec.ManagedClusters) > 0 {
+		var err error
+		if brokerInfo, err = broker.NewFromConfigMap(r.Client); err != nil {
+			klog.Errorf("Unable to read the broker info: %v", err)
+			return fmt.Errorf("unable to read the broker info: %v", err)
+		}
+	}
+
+	statuses := make([]operatorv1alpha2.ManagedClusterStatus, 0, len(instance.Spec.ManagedClusters))
+	var errs []error
+	for i := range instance.Spec.ManagedClusters {
+		managedCluster := &instance.Spec.ManagedClusters[i]
+		clusterID := managedCluster.JoinConfig.ClusterID
+		klog.Infof("Join managed cluster %s to submeriner broker", clusterID)
+
+		clusterInstance := newManagedClusterFabric(instance, managedCluster)
+		err := r.joinManagedCluster(instance, managedCluster, clusterInstance, brokerInfo)
+		status := operatorv1alpha2.ManagedClusterStatus{
+			ClusterID:  clusterID,
+			Phase:      operatorv1alpha2.PhaseRunning,
+			Network:    clusterInstance.Status.Network,
+			Conditions: clusterInstance.Status.Conditions,
+		}
+		if err != nil {
+			klog.Errorf("Unable to join managed cluster %s: %v", clusterID, err)
+			status.Phase = operatorv1alpha2.PhaseFailed
+			status.Message = err.Error()
+			errs = append(errs, fmt.Errorf("managed cluster %s: %v", clusterID, err))
+		}
+		statuses = append(statuses, status)
+	}
+	instance.Status.ManagedClusters = statuses
+	return utilerrors.NewAggregate(errs)
+}
+
+func (r *FabricReconciler) joinManagedCluster(instance *operatorv1alpha2.Fabric, managedCluster *operatorv1alpha2.ManagedCluster,
+	clusterInstance *operatorv1alpha2.Fabric, brokerInfo *broker.BrokerInfo) error {
+	managedReconciler, err := r.newManagedClusterReconciler(instance, managedCluster, brokerInfo)
+	if err != nil {
+		markStageFailed(clusterInstance, operatorv1alpha2.ConditionRequirementsMet, operatorv1alpha2.ReasonFailed,
+			fmt.Errorf("unable to connect to the managed cluster: %v", err))
+		return err
+	}
+	return managedReconciler.JoinSubmarinerCluster(clusterInstance, brokerInfo)
+}
+
+// UninstallManagedClusters reverts the join of every managed cluster listed in the fabric
+func (r *FabricReconciler) UninstallManagedClusters(instance *operatorv1alpha2.Fabric) error {
+	if len(instance.Spec.ManagedClusters) == 0 {
+		return nil
+	}
+	brokerInfo, err := broker.NewFromConfigMap(r.Client)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			klog.Info("Broker info not found, skip uninstalling the managed clusters")
+			return nil
+		}
+		return err
+	}
+
+	for i := range instance.Spec.ManagedClusters {
+		managedCluster := &instance.Spec.ManagedClusters[i]
+		clusterID := managedCluster.JoinConfig.ClusterID
+		klog.Infof("Uninstall submariner from managed cluster %s", clusterID)
+
+		managedReconciler, err := r.newManagedClusterReconciler(instance, managedCluster, brokerInfo)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				klog.Infof("Kubeconfig of managed cluster %s not found, skip uninstalling it", clusterID)
+				continue
+			}
+			return err
+		}
+		if err := managedReconciler.UninstallSubmarinerCluster(newManagedClusterFabric(instance, managedCluster)); err != nil {
+			klog.Errorf("Unable to uninstall managed cluster %s: %v", clusterID, err)
+			return err
+		}
+	}
+	return nil
+}
+
+// newManagedClusterReconciler returns a reconciler joining the managed cluster, with the clients bound to the
+// remote cluster and the broker info read from the broker cluster
+func (r *FabricReconciler) newManagedClusterReconciler(instance *operatorv1alpha2.Fabric, managedCluster *operatorv1alpha2.ManagedCluster,
+	brokerInfo *broker.BrokerInfo) (*FabricReconciler, error) {
+	restConfig, err := r.restConfigFromSecret(secretRefKey(&managedCluster.KubeConfigSecretRef, instance.GetNamespace()))
+	if err != nil {
+		return nil, err
+	}
+	managedClient, err := client.New(restConfig, client.Options{Scheme: r.Scheme})
+	if err != nil {
+		return nil, err
+	}
+	return &FabricReconciler{
+		Client:         managedClient,
+		Reader:         managedClient,
+		Config:         restConfig,
+		Scheme:         r.Scheme,
+		JoinBroker:     true,
+		brokerInfo:     brokerInfo,
+		managedCluster: true,
+	}, nil
+}
+
+// newManagedClusterFabric returns the fabric a managed cluster is joined with: the join config of the managed
+// cluster, and the status it was last joined with
+func newManagedClusterFabric(instance *operatorv1alpha2.Fabric, managedCluster *operatorv1alpha2.ManagedCluster) *operatorv1alpha2.Fabric {
+	clusterInstance := &operatorv1alpha2.Fabric{
+		ObjectMeta: *instance.ObjectMeta.DeepCopy(),
+	}
+	managedCluster.JoinConfig.DeepCopyInto(&clusterInstance.Spec.JoinConfig)
+	for i := range instance.Status.ManagedClusters {
+		status := &instance.Status.ManagedClusters[i]
+		if status.ClusterID == managedCluster.JoinConfig.ClusterID {
+			clusterInstance.Status.Network = status.Network.DeepCopy()
+			for j := range status.Conditions {
+				clusterInstance.Status.Conditions = append(clusterInstance.Status.Conditions, *status.Conditions[j].DeepCopy())
+			}
+		}
+	}
+	return clusterInstance
+}
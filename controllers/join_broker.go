@@ -20,19 +20,20 @@ import (
 	"context"
 	"encoding/base64"
 	"fmt"
-	"regexp"
+	"net"
 	"strings"
 	"time"
 
 	submariner "github.com/submariner-io/submariner-operator/apis/submariner/v1alpha1"
 
 	operatorv1alpha1 "github.com/DanielXLee/cluster-fabric-operator/api/v1alpha1"
+	operatorv1alpha2 "github.com/DanielXLee/cluster-fabric-operator/api/v1alpha2"
 	cmdVersion "github.com/DanielXLee/cluster-fabric-operator/controllers/checker"
 	"github.com/DanielXLee/cluster-fabric-operator/controllers/discovery/globalnet"
 	"github.com/DanielXLee/cluster-fabric-operator/controllers/discovery/network"
 	consts "github.com/DanielXLee/cluster-fabric-operator/controllers/ensures"
 	"github.com/DanielXLee/cluster-fabric-operator/controllers/ensures/broker"
-	"github.com/DanielXLee/cluster-fabric-operator/controllers/ensures/names"
+	"github.com/DanielXLee/cluster-fabric-operator/controllers/ensures/gateway"
 	"github.com/DanielXLee/cluster-fabric-operator/controllers/ensures/operator/servicediscoverycr"
 	"github.com/DanielXLee/cluster-fabric-operator/controllers/ensures/operator/submarinercr"
 	"github.com/DanielXLee/cluster-fabric-operator/controllers/ensures/operator/submarinerop"
@@ -45,12 +46,14 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/util/retry"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-var clienttoken *v1.Secret
+const kubeConfigSecretKey = "kubeconfig"
 
 var nodeLabelBackoff wait.Backoff = wait.Backoff{
 	Steps:    10,
@@ -59,11 +62,12 @@ var nodeLabelBackoff wait.Backoff = wait.Backoff{
 	Jitter:   1,
 }
 
-func (r *FabricReconciler) JoinSubmarinerCluster(instance *operatorv1alpha1.Fabric, brokerInfo *broker.BrokerInfo) error {
+func (r *FabricReconciler) JoinSubmarinerCluster(instance *operatorv1alpha2.Fabric, brokerInfo *broker.BrokerInfo) error {
 	joinConfig := instance.Spec.JoinConfig
 
 	if err := isValidCustomCoreDNSConfig(instance); err != nil {
 		klog.Errorf("Invalid Custom CoreDNS configuration: %v", err)
+		markStageFailed(instance, operatorv1alpha2.ConditionRequirementsMet, operatorv1alpha2.ReasonInvalidConfig, err)
 		return err
 	}
 
@@ -77,11 +81,22 @@ func (r *FabricReconciler) JoinSubmarinerCluster(instance *operatorv1alpha1.Fabr
 		// 	clusterID = *clusterName
 		// }
 		klog.Errorf("Invalid ClusterID")
-		return fmt.Errorf("invalid ClusterID")
+		err := fmt.Errorf("invalid ClusterID")
+		markStageFailed(instance, operatorv1alpha2.ConditionRequirementsMet, operatorv1alpha2.ReasonInvalidConfig, err)
+		return err
 	}
 
 	if valid, err := isValidClusterID(joinConfig.ClusterID); !valid {
 		klog.Errorf("Cluster ID invalid: %v", err)
+		markStageFailed(instance, operatorv1alpha2.ConditionRequirementsMet, operatorv1alpha2.ReasonInvalidConfig, err)
+		return err
+	}
+
+	if joinConfig.GlobalnetCIDR != "" && !brokerInfo.IsGlobalnetEnabled() {
+		// The global CIDR would be ignored, the cluster would join without the overlapping CIDRs support it asks for
+		err := fmt.Errorf("globalnetCIDR %s is set but globalnet is disabled on the broker", joinConfig.GlobalnetCIDR)
+		klog.Errorf("Invalid globalnet configuration: %v", err)
+		markStageFailed(instance, operatorv1alpha2.ConditionRequirementsMet, operatorv1alpha2.ReasonInvalidConfig, err)
 		return err
 	}
 
@@ -92,42 +107,56 @@ func (r *FabricReconciler) JoinSubmarinerCluster(instance *operatorv1alpha1.Fabr
 		for i := range failedRequirements {
 			klog.Infof("* %s", (failedRequirements)[i])
 		}
-		return fmt.Errorf("the target cluster fails to meet Submariner's requirements")
+		err := fmt.Errorf("the target cluster fails to meet Submariner's requirements: %s", strings.Join(failedRequirements, "; "))
+		markStageFailed(instance, operatorv1alpha2.ConditionRequirementsMet, operatorv1alpha2.ReasonFailed, err)
+		return err
 	}
 	if err != nil {
 		klog.Errorf("Unable to check all requirements: %v", err)
+		markStageFailed(instance, operatorv1alpha2.ConditionRequirementsMet, operatorv1alpha2.ReasonFailed, err)
 		return err
 	}
-	if brokerInfo.IsConnectivityEnabled() && joinConfig.LabelGateway {
+	if brokerInfo.IsConnectivityEnabled() && operatorv1alpha2.IsEnabled(joinConfig.LabelGateway) {
 		if err := r.HandleNodeLabels(); err != nil {
 			klog.Errorf("Unable to set the gateway node up: %v", err)
+			markStageFailed(instance, operatorv1alpha2.ConditionRequirementsMet, operatorv1alpha2.ReasonFailed,
+				fmt.Errorf("unable to set the gateway node up: %v", err))
 			return err
 		}
 	}
+	markStageSucceeded(instance, operatorv1alpha2.ConditionRequirementsMet, "The cluster meets Submariner's requirements")
 
 	klog.Info("Discovering network details")
 	networkDetails, err := r.GetNetworkDetails()
 	if err != nil {
 		klog.Errorf("Error get network details: %v", err)
+		markStageFailed(instance, operatorv1alpha2.ConditionNetworkDiscovered, operatorv1alpha2.ReasonFailed, err)
 		return err
 	}
 	serviceCIDR, serviceCIDRautoDetected, err := getServiceCIDR(joinConfig.ServiceCIDR, networkDetails)
 	if err != nil {
 		klog.Errorf("Error determining the service CIDR: %v", err)
+		markStageFailed(instance, operatorv1alpha2.ConditionNetworkDiscovered, operatorv1alpha2.ReasonFailed, err)
 		return err
 	}
 	clusterCIDR, clusterCIDRautoDetected, err := getPodCIDR(joinConfig.ClusterCIDR, networkDetails)
 	if err != nil {
 		klog.Errorf("Error determining the pod CIDR: %v", err)
+		markStageFailed(instance, operatorv1alpha2.ConditionNetworkDiscovered, operatorv1alpha2.ReasonFailed, err)
 		return err
 	}
+	markStageSucceeded(instance, operatorv1alpha2.ConditionNetworkDiscovered,
+		fmt.Sprintf("Pod CIDR %s, service CIDR %s", clusterCIDR, serviceCIDR))
 
 	brokerCluster, err := brokerInfo.GetBrokerAdministratorCluster()
 	if err != nil {
 		klog.Errorf("unable to get broker cluster client: %v", err)
+		markStageFailed(instance, operatorv1alpha2.ConditionBrokerReady, operatorv1alpha2.ReasonFailed,
+			fmt.Errorf("unable to get broker cluster client: %v", err))
 		return err
 	}
 	brokerNamespace := string(brokerInfo.ClientToken.Data["namespace"])
+	checkIPSecPSK(instance, brokerCluster.GetAPIReader(), brokerNamespace, brokerInfo)
 
 	netconfig := globalnet.Config{
 		ClusterID:               joinConfig.ClusterID,
@@ -135,12 +164,25 @@ func (r *FabricReconciler) JoinSubmarinerCluster(instance *operatorv1alpha1.Fabr
 		ServiceCIDRAutoDetected: serviceCIDRautoDetected,
 		ClusterCIDR:             clusterCIDR,
 		ClusterCIDRAutoDetected: clusterCIDRautoDetected,
-		GlobalnetCIDR:           brokerInfo.GlobalnetCIDRRange,
-		GlobalnetClusterSize:    brokerInfo.DefaultGlobalnetClusterSize,
+		GlobalnetCIDR:           joinConfig.GlobalnetCIDR,
+		GlobalnetClusterSize:    joinConfig.GlobalnetClusterSize,
 	}
+	instance.Status.Network = newNetworkStatus(networkDetails, &netconfig)
+
 	if brokerInfo.IsGlobalnetEnabled() {
-		if err = r.AllocateAndUpdateGlobalCIDRConfigMap(brokerCluster.GetClient(), brokerCluster.GetAPIReader(), instance, brokerNamespace, &netconfig); err != nil {
+		if err = r.AllocateAndUpdateGlobalCIDR(brokerCluster.GetClient(), brokerCluster.GetAPIReader(), instance, brokerNamespace, &netconfig); err != nil {
 			klog.Errorf("Error Discovering multi cluster details: %v", err)
+			markStageFailed(instance, operatorv1alpha2.ConditionGlobalnetAllocated, operatorv1alpha2.ReasonFailed, err)
+			return err
+		}
+		instance.Status.Network.GlobalCIDR = netconfig.GlobalCIDRs[0]
+		instance.Status.Network.GlobalCIDRs = netconfig.GlobalCIDRs
+		markStageSucceeded(instance, operatorv1alpha2.ConditionGlobalnetAllocated,
+			fmt.Sprintf("Global CIDRs %s allocated", strings.Join(netconfig.GlobalCIDRs, ", ")))
+		clearStage(instance, operatorv1alpha2.ConditionCIDRsDisjoint)
+	} else {
+		clearStage(instance, operatorv1alpha2.ConditionGlobalnetAllocated)
+		if err := checkOverlappingClusters(instance, brokerCluster.GetAPIReader(), brokerNamespace, netconfig); err != nil {
 			return err
 		}
 	}
@@ -148,41 +190,180 @@ func (r *FabricReconciler) JoinSubmarinerCluster(instance *operatorv1alpha1.Fabr
 	klog.Info("Deploying the Submariner operator")
 	if err = submarinerop.Ensure(r.Client, r.Config, true); err != nil {
 		klog.Errorf("Error deploying the operator: %v", err)
+		markStageFailed(instance, operatorv1alpha2.ConditionOperatorDeployed, operatorv1alpha2.ReasonFailed, err)
 		return err
 	}
+	markStageSucceeded(instance, operatorv1alpha2.ConditionOperatorDeployed, "The submariner operator is deployed")
+
 	klog.Info("Creating SA for cluster")
-	clienttoken, err = broker.CreateSAForCluster(brokerCluster.GetClient(), brokerCluster.GetAPIReader(), joinConfig.ClusterID)
+	clientToken, err := broker.CreateSAForCluster(brokerCluster.GetClient(), brokerCluster.GetAPIReader(), joinConfig.ClusterID)
 	if err != nil {
 		klog.Errorf("Error creating SA for cluster: %v", err)
+		markStageFailed(instance, operatorv1alpha2.ConditionBrokerReady, operatorv1alpha2.ReasonFailed, err)
 		return err
 	}
+	markStageSucceeded(instance, operatorv1alpha2.ConditionBrokerReady,
+		fmt.Sprintf("Cluster %s is registered on broker %s", joinConfig.ClusterID, brokerInfo.BrokerURL))
+
 	if brokerInfo.IsConnectivityEnabled() {
 		klog.Info("Deploying Submariner")
-		submarinerSpec, err := populateSubmarinerSpec(instance, brokerInfo, netconfig)
+		submarinerSpec, err := populateSubmarinerSpec(instance, brokerInfo, netconfig, clientToken)
 		if err != nil {
+			markStageFailed(instance, operatorv1alpha2.ConditionSubmarinerDeployed, operatorv1alpha2.ReasonInvalidConfig, err)
+			return err
+		}
+		if err = r.ensureGatewayLoadBalancer(instance); err != nil {
+			klog.Errorf("Unable to set the gateway load balancer up: %v", err)
+			markStageFailed(instance, operatorv1alpha2.ConditionSubmarinerDeployed, operatorv1alpha2.ReasonFailed,
+				fmt.Errorf("unable to set the gateway load balancer up: %v", err))
 			return err
 		}
 		if err = submarinercr.Ensure(r.Client, consts.SubmarinerOperatorNamespace, submarinerSpec); err != nil {
 			klog.Errorf("Submariner deployment failed: %v", err)
+			markStageFailed(instance, operatorv1alpha2.ConditionSubmarinerDeployed, operatorv1alpha2.ReasonFailed, err)
 			return err
 		}
+		markStageSucceeded(instance, operatorv1alpha2.ConditionSubmarinerDeployed, "Submariner is up and running")
+		clearStage(instance, operatorv1alpha2.ConditionServiceDiscoveryDeployed)
 		klog.Info("Submariner is up and running")
 	} else if brokerInfo.IsServiceDiscoveryEnabled() {
 		klog.Info("Deploying service discovery only")
-		serviceDiscoverySpec, err := populateServiceDiscoverySpec(instance, brokerInfo)
+		serviceDiscoverySpec, err := populateServiceDiscoverySpec(instance, brokerInfo, clientToken)
 		if err != nil {
+			markStageFailed(instance, operatorv1alpha2.ConditionServiceDiscoveryDeployed, operatorv1alpha2.ReasonInvalidConfig, err)
 			return err
 		}
 		if err = servicediscoverycr.Ensure(r.Client, consts.SubmarinerOperatorNamespace, serviceDiscoverySpec); err != nil {
 			klog.Errorf("Service discovery deployment failed: %v", err)
+			markStageFailed(instance, operatorv1alpha2.ConditionServiceDiscoveryDeployed, operatorv1alpha2.ReasonFailed, err)
 			return err
 		}
+		markStageSucceeded(instance, operatorv1alpha2.ConditionServiceDiscoveryDeployed, "Service discovery is up and running")
+		clearStage(instance, operatorv1alpha2.ConditionSubmarinerDeployed)
 		klog.Info("Service discovery is up and running")
 	}
 	return nil
 }
 
-func (r *FabricReconciler) AllocateAndUpdateGlobalCIDRConfigMap(c client.Client, reader client.Reader, instance *operatorv1alpha1.Fabric, brokerNamespace string,
+// ensureGatewayLoadBalancer puts a LoadBalancer service in front of the gateways when the join config asks for it,
+// and removes it otherwise
+func (r *FabricReconciler) ensureGatewayLoadBalancer(instance *operatorv1alpha2.Fabric) error {
+	if !instance.Spec.JoinConfig.LoadBalancerEnabled {
+		instance.Status.Network.LoadBalancerAddress = ""
+		return gateway.DeleteLoadBalancer(r.Client, consts.SubmarinerOperatorNamespace)
+	}
+
+	klog.Info("Setting the gateway load balancer up")
+	svc, err := gateway.EnsureLoadBalancer(r.Client, instance, consts.SubmarinerOperatorNamespace)
+	if err != nil {
+		return err
+	}
+	instance.Status.Network.LoadBalancerAddress = gateway.LoadBalancerAddress(svc)
+	if instance.Status.Network.LoadBalancerAddress == "" {
+		klog.Infof("The gateway load balancer %s has no external address yet", gateway.LoadBalancerName)
+	}
+	return nil
+}
+
+// GetBrokerInfo reads the broker info from the source configured in the join config: a local secret,
+// the secret exported on the broker cluster, or the broker info configmap of the local cluster
+func (r *FabricReconciler) GetBrokerInfo(instance *operatorv1alpha2.Fabric) (*broker.BrokerInfo, error) {
+	joinConfig := instance.Spec.JoinConfig
+	if joinConfig.BrokerInfoSecretRef != nil {
+		return broker.NewFromSecret(r.Client, secretRefKey(joinConfig.BrokerInfoSecretRef, instance.GetNamespace()))
+	}
+
+	if r.brokerInfo != nil {
+		return r.brokerInfo, nil
+	}
+
+	if joinConfig.BrokerKubeConfigSecretRef != nil {
+		brokerConfig, err := r.restConfigFromSecret(secretRefKey(joinConfig.BrokerKubeConfigSecretRef, instance.GetNamespace()))
+		if err != nil {
+			return nil, err
+		}
+		brokerClient, err := client.New(brokerConfig, client.Options{Scheme: r.Scheme})
+		if err != nil {
+			return nil, err
+		}
+		brokerInfoKey := types.NamespacedName{Name: consts.SubmarinerBrokerInfo, Namespace: consts.SubmarinerBrokerNamespace}
+		return broker.NewFromSecret(brokerClient, brokerInfoKey)
+	}
+
+	return broker.NewFromConfigMap(r.Client)
+}
+
+// restConfigFromSecret builds the rest config of a remote cluster from the kubeconfig held in a secret
+func (r *FabricReconciler) restConfigFromSecret(secretKey types.NamespacedName) (*rest.Config, error) {
+	kubeConfigSecret := &v1.Secret{}
+	if err := r.Client.Get(context.TODO(), secretKey, kubeConfigSecret); err != nil {
+		return nil, err
+	}
+	restConfig, err := clientcmd.RESTConfigFromKubeConfig(kubeConfigSecret.Data[kubeConfigSecretKey])
+	if err != nil {
+		return nil, fmt.Errorf("invalid kubeconfig in secret %s: %v", secretKey, err)
+	}
+	return restConfig, nil
+}
+
+func secretRefKey(ref *v1.SecretReference, defaultNamespace string) types.NamespacedName {
+	namespace := ref.Namespace
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+	return types.NamespacedName{Name: ref.Name, Namespace: namespace}
+}
+
+// checkOverlappingClusters refuses to join a cluster whose CIDRs overlap a joined cluster, without globalnet
+// Submariner can't route between them
+func checkOverlappingClusters(instance *operatorv1alpha2.Fabric, reader client.Reader, brokerNamespace string,
+	netconfig globalnet.Config) error {
+	overlaps, err := globalnet.FindOverlappingClusters(reader, brokerNamespace, netconfig)
+	if err != nil {
+		klog.Errorf("Unable to check the CIDRs of the joined clusters: %v", err)
+		markStageFailed(instance, operatorv1alpha2.ConditionCIDRsDisjoint, operatorv1alpha2.ReasonFailed,
+			fmt.Errorf("unable to check the CIDRs of the joined clusters: %v", err))
+		return err
+	}
+	if len(overlaps) > 0 {
+		err := fmt.Errorf("%s, enable globalnet on the broker to join clusters with overlapping CIDRs",
+			strings.Join(overlaps, "; "))
+		klog.Errorf("The cluster CIDRs overlap joined clusters: %v", err)
+		markStageFailed(instance, operatorv1alpha2.ConditionCIDRsDisjoint, operatorv1alpha2.ReasonOverlappingCIDRs, err)
+		return err
+	}
+	markStageSucceeded(instance, operatorv1alpha2.ConditionCIDRsDisjoint, "The cluster CIDRs overlap no joined cluster")
+	return nil
+}
+
+// checkIPSecPSK flags the clusters reading a copy of the broker info whose IPsec PSK the broker rotated since,
+// the copy has to be refreshed for the cluster to connect to the others again. The join goes on regardless.
+func checkIPSecPSK(instance *operatorv1alpha2.Fabric, reader client.Reader, brokerNamespace string, brokerInfo *broker.BrokerInfo) {
+	if instance.Spec.JoinConfig.BrokerInfoSecretRef == nil {
+		// The broker info is read from the broker on every resync, the PSK is current
+		clearStage(instance, operatorv1alpha2.ConditionIPSecPSKCurrent)
+		return
+	}
+	current, err := brokerInfo.HasCurrentIPSecPSK(reader, brokerNamespace)
+	if err != nil {
+		klog.Errorf("Unable to read the IPsec PSK of the broker: %v", err)
+		markStageFailed(instance, operatorv1alpha2.ConditionIPSecPSKCurrent, operatorv1alpha2.ReasonFailed,
+			fmt.Errorf("unable to read the IPsec PSK of the broker: %v", err))
+		return
+	}
+	if !current {
+		secretKey := secretRefKey(instance.Spec.JoinConfig.BrokerInfoSecretRef, instance.GetNamespace())
+		klog.Warningf("The broker info secret %s holds a rotated IPsec PSK", secretKey)
+		markStageFailed(instance, operatorv1alpha2.ConditionIPSecPSKCurrent, operatorv1alpha2.ReasonStaleIPSecPSK,
+			fmt.Errorf("the broker info secret %s holds an IPsec PSK the broker rotated since, copy it again from the broker cluster", secretKey))
+		return
+	}
+	markStageSucceeded(instance, operatorv1alpha2.ConditionIPSecPSKCurrent, "The broker info holds the current IPsec PSK")
+}
+
+// AllocateAndUpdateGlobalCIDR allocates the global CIDR of the cluster from the globalnet supernet of the broker,
+// and records it on the broker
+func (r *FabricReconciler) AllocateAndUpdateGlobalCIDR(c client.Client, reader client.Reader, instance *operatorv1alpha2.Fabric, brokerNamespace string,
 	netconfig *globalnet.Config) error {
 	joinConfig := instance.Spec.JoinConfig
 	klog.Info("Discovering multi cluster details")
@@ -200,26 +381,34 @@ func (r *FabricReconciler) AllocateAndUpdateGlobalCIDRConfigMap(c client.Client,
 		}
 
 		if globalnetInfo.GlobalnetEnabled {
-			netconfig.GlobalnetCIDR, err = globalnet.AssignGlobalnetIPs(globalnetInfo, *netconfig)
+			netconfig.GlobalCIDRs, err = globalnet.AssignGlobalnetIPs(globalnetInfo, *netconfig)
 			if err != nil {
 				klog.Errorf("error assigning Globalnet IPs: %v", err)
 				return err
 			}
 
-			if globalnetInfo.GlobalCidrInfo[joinConfig.ClusterID] == nil ||
-				globalnetInfo.GlobalCidrInfo[joinConfig.ClusterID].GlobalCIDRs[0] != netconfig.GlobalnetCIDR {
-				var newClusterInfo broker.ClusterInfo
-				newClusterInfo.ClusterID = joinConfig.ClusterID
-				newClusterInfo.GlobalCidr = []string{netconfig.GlobalnetCIDR}
+			// Recorded even when unchanged, so the allocation released by a former un-join is taken back
+			var newClusterInfo broker.ClusterInfo
+			newClusterInfo.ClusterID = joinConfig.ClusterID
+			newClusterInfo.GlobalCidr = netconfig.GlobalCIDRs
 
-				return broker.UpdateGlobalnetConfigMap(c, brokerNamespace, globalnetConfigMap, newClusterInfo)
-			}
+			return broker.RecordGlobalCIDRs(c, reader, brokerNamespace, globalnetConfigMap, newClusterInfo, r.allocationOwner(instance))
 		}
 		return err
 	})
 	return retryErr
 }
 
+// allocationOwner returns the owner of the global CIDR allocation of the cluster the fabric joins, each managed
+// cluster of a fabric in hub mode owns its allocation
+func (r *FabricReconciler) allocationOwner(instance *operatorv1alpha2.Fabric) *operatorv1alpha1.AllocationOwner {
+	owner := &operatorv1alpha1.AllocationOwner{Name: instance.GetName(), Namespace: instance.GetNamespace()}
+	if r.managedCluster {
+		owner.ClusterID = instance.Spec.JoinConfig.ClusterID
+	}
+	return owner
+}
+
 func (r *FabricReconciler) GetNetworkDetails() (*network.ClusterNetwork, error) {
 	dynClient, err := dynamic.NewForConfig(r.Config)
 	if err != nil {
@@ -229,49 +418,87 @@ func (r *FabricReconciler) GetNetworkDetails() (*network.ClusterNetwork, error)
 	networkDetails, err := network.Discover(dynClient, r.Client, consts.SubmarinerOperatorNamespace)
 	if err != nil {
 		klog.Errorf("Error trying to discover network details: %v", err)
-	} else if networkDetails != nil {
-		networkDetails.Show()
+	} else {
+		networkDetails.Log()
 	}
 	return networkDetails, nil
 }
 
+// newNetworkStatus records what the network discovery found and which CIDRs the join actually uses
+func newNetworkStatus(nd *network.ClusterNetwork, netconfig *globalnet.Config) *operatorv1alpha2.NetworkStatus {
+	status := &operatorv1alpha2.NetworkStatus{
+		ClusterCIDR:             netconfig.ClusterCIDR,
+		ClusterCIDRAutoDetected: netconfig.ClusterCIDRAutoDetected,
+		ServiceCIDR:             netconfig.ServiceCIDR,
+		ServiceCIDRAutoDetected: netconfig.ServiceCIDRAutoDetected,
+	}
+	if nd != nil {
+		status.NetworkPlugin = nd.NetworkPlugin
+		status.DiscoveredPodCIDRs = nd.PodCIDRs
+		status.DiscoveredServiceCIDRs = nd.ServiceCIDRs
+	}
+	return status
+}
+
+// getPodCIDR returns the pod CIDR of each IP family of the cluster, comma separated as Submariner expects them
 func getPodCIDR(clusterCIDR string, nd *network.ClusterNetwork) (cidrType string, autodetected bool, err error) {
 	if clusterCIDR != "" {
-		if nd != nil && len(nd.PodCIDRs) > 0 && nd.PodCIDRs[0] != clusterCIDR {
+		if nd != nil && len(nd.PodCIDRs) > 0 && dualStackCIDR(nd.PodCIDRs) != clusterCIDR {
 			klog.Warningf("Your provided cluster CIDR for the pods (%s) does not match discovered (%s)",
-				clusterCIDR, nd.PodCIDRs[0])
+				clusterCIDR, dualStackCIDR(nd.PodCIDRs))
 		}
 		return clusterCIDR, false, nil
 	} else if nd != nil && len(nd.PodCIDRs) > 0 {
-		return nd.PodCIDRs[0], true, nil
+		return dualStackCIDR(nd.PodCIDRs), true, nil
 	}
 	return "", true, fmt.Errorf("not found invalidate cluster CIDR")
 }
 
+// getServiceCIDR returns the service CIDR of each IP family of the cluster, comma separated as Submariner expects them
 func getServiceCIDR(serviceCIDR string, nd *network.ClusterNetwork) (cidrType string, autodetected bool, err error) {
 	if serviceCIDR != "" {
-		if nd != nil && len(nd.ServiceCIDRs) > 0 && nd.ServiceCIDRs[0] != serviceCIDR {
+		if nd != nil && len(nd.ServiceCIDRs) > 0 && dualStackCIDR(nd.ServiceCIDRs) != serviceCIDR {
 			klog.Warningf("Your provided service CIDR (%s) does not match discovered (%s)",
-				serviceCIDR, nd.ServiceCIDRs[0])
+				serviceCIDR, dualStackCIDR(nd.ServiceCIDRs))
 		}
 		return serviceCIDR, false, nil
 	} else if nd != nil && len(nd.ServiceCIDRs) > 0 {
-		return nd.ServiceCIDRs[0], true, nil
+		return dualStackCIDR(nd.ServiceCIDRs), true, nil
 	}
 	return "", true, fmt.Errorf("not found invalidate service CIDR")
 }
 
+// dualStackCIDR joins the first discovered CIDR of each IP family, the first one alone on single-stack clusters
+func dualStackCIDR(cidrs []string) string {
+	families := map[bool]bool{}
+	dualStack := []string{}
+	for _, cidr := range cidrs {
+		ip, _, err := net.ParseCIDR(cidr)
+		if err != nil {
+			continue
+		}
+		ipv6 := ip.To4() == nil
+		if families[ipv6] {
+			continue
+		}
+		families[ipv6] = true
+		dualStack = append(dualStack, cidr)
+	}
+	if len(dualStack) == 0 {
+		return cidrs[0]
+	}
+	return strings.Join(dualStack, ",")
+}
+
 func isValidClusterID(clusterID string) (bool, error) {
-	// Make sure the clusterid is a valid DNS-1123 string
-	if match, _ := regexp.MatchString("^[a-z0-9][a-z0-9.-]*[a-z0-9]$", clusterID); !match {
-		return false, fmt.Errorf("cluster IDs must be valid DNS-1123 names, with only lowercase alphanumerics,\n"+
-			"'.' or '-' (and the first and last characters must be alphanumerics).\n"+
-			"%s doesn't meet these requirements", clusterID)
+	if err := operatorv1alpha2.ValidateClusterID(clusterID); err != nil {
+		return false, err
 	}
 	return true, nil
 }
 
-func populateSubmarinerSpec(instance *operatorv1alpha1.Fabric, brokerInfo *broker.BrokerInfo, netconfig globalnet.Config) (*submariner.SubmarinerSpec, error) {
+func populateSubmarinerSpec(instance *operatorv1alpha2.Fabric, brokerInfo *broker.BrokerInfo, netconfig globalnet.Config,
+	clientToken *v1.Secret) (*submariner.SubmarinerSpec, error) {
 	joinConfig := instance.Spec.JoinConfig
 	brokerURL := brokerInfo.BrokerURL
 	if idx := strings.Index(brokerURL, "://"); idx >= 0 {
@@ -290,10 +517,6 @@ func populateSubmarinerSpec(instance *operatorv1alpha1.Fabric, brokerInfo *broke
 	if !netconfig.ClusterCIDRAutoDetected {
 		crClusterCIDR = netconfig.ClusterCIDR
 	}
-	// customDomains := ""
-	if joinConfig.CustomDomains == nil && brokerInfo.CustomDomains != nil {
-		joinConfig.CustomDomains = *brokerInfo.CustomDomains
-	}
 	imageOverrides, err := getImageOverrides(instance)
 	if err != nil {
 		return nil, err
@@ -309,10 +532,10 @@ func populateSubmarinerSpec(instance *operatorv1alpha1.Fabric, brokerInfo *broke
 		CeIPSecPSK:               base64.StdEncoding.EncodeToString(brokerInfo.IPSecPSK.Data["psk"]),
 		BrokerK8sCA:              base64.StdEncoding.EncodeToString(brokerInfo.ClientToken.Data["ca.crt"]),
 		BrokerK8sRemoteNamespace: string(brokerInfo.ClientToken.Data["namespace"]),
-		BrokerK8sApiServerToken:  string(clienttoken.Data["token"]),
+		BrokerK8sApiServerToken:  string(clientToken.Data["token"]),
 		BrokerK8sApiServer:       brokerURL,
 		Broker:                   "k8s",
-		NatEnabled:               joinConfig.NatTraversal,
+		NatEnabled:               operatorv1alpha2.IsEnabled(joinConfig.NatTraversal),
 		Debug:                    joinConfig.SubmarinerDebug,
 		ClusterID:                joinConfig.ClusterID,
 		ServiceCIDR:              crServiceCIDR,
@@ -321,10 +544,15 @@ func populateSubmarinerSpec(instance *operatorv1alpha1.Fabric, brokerInfo *broke
 		CableDriver:              joinConfig.CableDriver,
 		ServiceDiscoveryEnabled:  brokerInfo.IsServiceDiscoveryEnabled(),
 		ImageOverrides:           imageOverrides,
-		GlobalCIDR:               brokerInfo.GlobalnetCIDRRange,
+		ConnectionHealthCheck: &submariner.HealthCheckSpec{
+			Enabled:            operatorv1alpha2.IsEnabled(joinConfig.HealthCheckEnable),
+			IntervalSeconds:    joinConfig.HealthCheckInterval,
+			MaxPacketLossCount: joinConfig.HealthCheckMaxPacketLossCount,
+		},
 	}
-	if netconfig.GlobalnetCIDR != "" {
-		submarinerSpec.GlobalCIDR = netconfig.GlobalnetCIDR
+	// Only the clusters of a globalnet broker get global CIDRs, comma separated as Submariner expects them
+	if brokerInfo.IsGlobalnetEnabled() {
+		submarinerSpec.GlobalCIDR = strings.Join(netconfig.GlobalCIDRs, ",")
 	}
 	if joinConfig.CorednsCustomConfigMap != "" {
 		namespace, name := getCustomCoreDNSParams(instance)
@@ -333,13 +561,22 @@ func populateSubmarinerSpec(instance *operatorv1alpha1.Fabric, brokerInfo *broke
 			Namespace:     namespace,
 		}
 	}
-	if brokerInfo.CustomDomains != nil && len(*brokerInfo.CustomDomains) > 0 {
-		submarinerSpec.CustomDomains = *brokerInfo.CustomDomains
+	if customDomains := getCustomDomains(instance, brokerInfo); len(customDomains) > 0 {
+		submarinerSpec.CustomDomains = customDomains
 	}
 	return submarinerSpec, nil
 }
 
-func getImageVersion(instance *operatorv1alpha1.Fabric) string {
+// getCustomDomains returns the custom domains of the join config, the ones of the broker when they are unset
+func getCustomDomains(instance *operatorv1alpha2.Fabric, brokerInfo *broker.BrokerInfo) []string {
+	customDomains := instance.Spec.JoinConfig.CustomDomains
+	if customDomains == nil && brokerInfo.CustomDomains != nil {
+		customDomains = *brokerInfo.CustomDomains
+	}
+	return customDomains
+}
+
+func getImageVersion(instance *operatorv1alpha2.Fabric) string {
 	version := instance.Spec.JoinConfig.ImageVersion
 
 	if version == "" {
@@ -349,7 +586,7 @@ func getImageVersion(instance *operatorv1alpha1.Fabric) string {
 	return version
 }
 
-func getImageRepo(instance *operatorv1alpha1.Fabric) string {
+func getImageRepo(instance *operatorv1alpha2.Fabric) string {
 	repo := instance.Spec.JoinConfig.Repository
 
 	if repo == "" {
@@ -368,23 +605,21 @@ func removeSchemaPrefix(brokerURL string) string {
 	return brokerURL
 }
 
-func populateServiceDiscoverySpec(instance *operatorv1alpha1.Fabric, brokerInfo *broker.BrokerInfo) (*submariner.ServiceDiscoverySpec, error) {
+func populateServiceDiscoverySpec(instance *operatorv1alpha2.Fabric, brokerInfo *broker.BrokerInfo,
+	clientToken *v1.Secret) (*submariner.ServiceDiscoverySpec, error) {
 	brokerURL := removeSchemaPrefix(brokerInfo.BrokerURL)
 	joinConfig := instance.Spec.JoinConfig
-	var customDomains []string
-	if joinConfig.CustomDomains == nil && brokerInfo.CustomDomains != nil {
-		customDomains = *brokerInfo.CustomDomains
-	}
+	customDomains := getCustomDomains(instance, brokerInfo)
 	imageOverrides, err := getImageOverrides(instance)
 	if err != nil {
 		return nil, err
 	}
 	serviceDiscoverySpec := submariner.ServiceDiscoverySpec{
-		Repository:               joinConfig.Repository,
-		Version:                  joinConfig.ImageVersion,
+		Repository:               getImageRepo(instance),
+		Version:                  getImageVersion(instance),
 		BrokerK8sCA:              base64.StdEncoding.EncodeToString(brokerInfo.ClientToken.Data["ca.crt"]),
 		BrokerK8sRemoteNamespace: string(brokerInfo.ClientToken.Data["namespace"]),
-		BrokerK8sApiServerToken:  string(clienttoken.Data["token"]),
+		BrokerK8sApiServerToken:  string(clientToken.Data["token"]),
 		BrokerK8sApiServer:       brokerURL,
 		Debug:                    joinConfig.SubmarinerDebug,
 		ClusterID:                joinConfig.ClusterID,
@@ -407,17 +642,16 @@ func populateServiceDiscoverySpec(instance *operatorv1alpha1.Fabric, brokerInfo
 	return &serviceDiscoverySpec, nil
 }
 
-func getImageOverrides(instance *operatorv1alpha1.Fabric) (map[string]string, error) {
+func getImageOverrides(instance *operatorv1alpha2.Fabric) (map[string]string, error) {
 	joinConfig := instance.Spec.JoinConfig
 	if len(joinConfig.ImageOverrideArr) > 0 {
 		imageOverrides := make(map[string]string)
 		for _, s := range joinConfig.ImageOverrideArr {
-			key := strings.Split(s, "=")[0]
-			if invalidImageName(key) {
-				klog.Errorf("Invalid image name %s provided. Please choose from %q", key, names.ValidImageNames)
-				return nil, fmt.Errorf("invalid image name %s provided. Please choose from %q", key, names.ValidImageNames)
+			key, value, err := operatorv1alpha2.ParseImageOverride(s)
+			if err != nil {
+				klog.Errorf("Invalid image override: %v", err)
+				return nil, err
 			}
-			value := strings.Split(s, "=")[1]
 			imageOverrides[key] = value
 		}
 		return imageOverrides, nil
@@ -425,25 +659,15 @@ func getImageOverrides(instance *operatorv1alpha1.Fabric) (map[string]string, er
 	return nil, nil
 }
 
-func invalidImageName(key string) bool {
-	for _, name := range names.ValidImageNames {
-		if key == name {
-			return false
-		}
-	}
-	return true
-}
-
-func isValidCustomCoreDNSConfig(instance *operatorv1alpha1.Fabric) error {
-	corednsCustomConfigMap := instance.Spec.JoinConfig.CorednsCustomConfigMap
-	if corednsCustomConfigMap != "" && strings.Count(corednsCustomConfigMap, "/") > 1 {
-		klog.Error("coredns-custom-configmap should be in <namespace>/<name> format, namespace is optional")
-		return fmt.Errorf("coredns-custom-configmap should be in <namespace>/<name> format, namespace is optional")
+func isValidCustomCoreDNSConfig(instance *operatorv1alpha2.Fabric) error {
+	if err := operatorv1alpha2.ValidateCorednsCustomConfigMap(instance.Spec.JoinConfig.CorednsCustomConfigMap); err != nil {
+		klog.Error(err)
+		return err
 	}
 	return nil
 }
 
-func getCustomCoreDNSParams(instance *operatorv1alpha1.Fabric) (namespace, name string) {
+func getCustomCoreDNSParams(instance *operatorv1alpha2.Fabric) (namespace, name string) {
 	corednsCustomConfigMap := instance.Spec.JoinConfig.CorednsCustomConfigMap
 	if corednsCustomConfigMap != "" {
 		name = corednsCustomConfigMap
@@ -0,0 +1,72 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+
+package controllers
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	operatorv1alpha1 "github.com/DanielXLee/cluster-fabric-operator/api/v1alpha1"
+	operatorv1alpha2 "github.com/DanielXLee/cluster-fabric-operator/api/v1alpha2"
+	"github.com/DanielXLee/cluster-fabric-operator/controllers/components"
+	"github.com/DanielXLee/cluster-fabric-operator/controllers/discovery/globalnet"
+	consts "github.com/DanielXLee/cluster-fabric-operator/controllers/ensures"
+	"github.com/DanielXLee/cluster-fabric-operator/controllers/ensures/broker"
+	"github.com/DanielXLee/cluster-fabric-operator/controllers/stringset"
+)
+
+var _ = Describe("Join broker", func() {
+	It("Should allocate and propagate an additional global CIDR when the cluster size is raised", func() {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(operatorv1alpha1.AddToScheme(scheme)).To(Succeed())
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		Expect(broker.CreateGlobalnetConfigMap(c, true, "169.254.0.0/16", 8192, consts.SubmarinerBrokerNamespace)).To(Succeed())
+		r := &FabricReconciler{Client: c, Reader: c, Scheme: scheme, JoinBroker: true}
+		fabric := &operatorv1alpha2.Fabric{Spec: operatorv1alpha2.FabricSpec{
+			JoinConfig: operatorv1alpha2.JoinConfig{ClusterID: "cluster1"},
+		}}
+
+		netconfig := &globalnet.Config{ClusterID: "cluster1"}
+		Expect(r.AllocateAndUpdateGlobalCIDR(c, c, fabric, consts.SubmarinerBrokerNamespace, netconfig)).To(Succeed())
+		Expect(netconfig.GlobalCIDRs).To(Equal([]string{"169.254.0.0/19"}))
+
+		fabric.Spec.JoinConfig.GlobalnetClusterSize = 16384
+		netconfig = &globalnet.Config{ClusterID: "cluster1", GlobalnetClusterSize: 16384}
+		Expect(r.AllocateAndUpdateGlobalCIDR(c, c, fabric, consts.SubmarinerBrokerNamespace, netconfig)).To(Succeed())
+		Expect(netconfig.GlobalCIDRs).To(Equal([]string{"169.254.0.0/19", "169.254.32.0/19"}))
+
+		allocation := &operatorv1alpha1.GlobalCIDRAllocation{}
+		allocationKey := types.NamespacedName{Name: "cluster1", Namespace: consts.SubmarinerBrokerNamespace}
+		Expect(c.Get(context.TODO(), allocationKey, allocation)).To(Succeed())
+		Expect(allocation.Spec.GlobalCIDRs).To(Equal(netconfig.GlobalCIDRs))
+
+		brokerInfo := &broker.BrokerInfo{ClientToken: &v1.Secret{}, IPSecPSK: &v1.Secret{}}
+		brokerInfo.SetComponents(stringset.New(components.Connectivity, components.Globalnet))
+		submarinerSpec, err := populateSubmarinerSpec(fabric, brokerInfo, *netconfig, &v1.Secret{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(submarinerSpec.GlobalCIDR).To(Equal("169.254.0.0/19,169.254.32.0/19"))
+	})
+})
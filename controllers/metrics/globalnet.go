@@ -0,0 +1,82 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"math/big"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/DanielXLee/cluster-fabric-operator/controllers/discovery/globalnet"
+)
+
+const cidrRangeLabel = "cidr_range"
+
+var (
+	supernetIPs         = newGlobalnetGauge("supernet_ips", "Amount of IPs of the globalnet supernet")
+	allocatedBlocks     = newGlobalnetGauge("allocated_blocks", "Amount of global CIDRs allocated from the globalnet supernet")
+	allocatedIPs        = newGlobalnetGauge("allocated_ips", "Amount of IPs of the globalnet supernet allocated to the clusters")
+	freeIPs             = newGlobalnetGauge("free_ips", "Amount of IPs of the globalnet supernet not allocated yet")
+	largestFreeBlockIPs = newGlobalnetGauge("largest_free_block_ips",
+		"Amount of IPs of the largest contiguous free range of the globalnet supernet")
+	fragmentationRatio = newGlobalnetGauge("fragmentation_ratio",
+		"Share of the free IPs of the globalnet supernet outside its largest free range")
+
+	globalnetGauges = []*prometheus.GaugeVec{
+		supernetIPs, allocatedBlocks, allocatedIPs, freeIPs, largestFreeBlockIPs, fragmentationRatio,
+	}
+)
+
+func init() {
+	for _, gauge := range globalnetGauges {
+		metrics.Registry.MustRegister(gauge)
+	}
+}
+
+func newGlobalnetGauge(name, help string) *prometheus.GaugeVec {
+	return prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "fabric",
+		Subsystem: "globalnet",
+		Name:      name,
+		Help:      help,
+	}, []string{cidrRangeLabel})
+}
+
+// RecordGlobalnetCapacity exposes the capacity of the globalnet supernet, a nil capacity removes it when
+// globalnet is disabled
+func RecordGlobalnetCapacity(capacity *globalnet.SupernetCapacity) {
+	// The supernet may have changed, only the current one is exposed
+	for _, gauge := range globalnetGauges {
+		gauge.Reset()
+	}
+	if capacity == nil {
+		return
+	}
+	labels := prometheus.Labels{cidrRangeLabel: capacity.CIDRRange}
+	supernetIPs.With(labels).Set(toFloat(capacity.TotalIPs))
+	allocatedBlocks.With(labels).Set(float64(capacity.AllocatedBlocks))
+	allocatedIPs.With(labels).Set(toFloat(capacity.AllocatedIPs))
+	freeIPs.With(labels).Set(toFloat(capacity.FreeIPs))
+	largestFreeBlockIPs.With(labels).Set(toFloat(capacity.LargestFreeIPs))
+	fragmentationRatio.With(labels).Set(capacity.Fragmentation)
+}
+
+func toFloat(value *big.Int) float64 {
+	f, _ := new(big.Float).SetInt(value).Float64()
+	return f
+}
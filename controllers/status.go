@@ -0,0 +1,51 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	operatorv1alpha2 "github.com/DanielXLee/cluster-fabric-operator/api/v1alpha2"
+)
+
+// markStageSucceeded records a successful reconciliation stage on the fabric status
+func markStageSucceeded(instance *operatorv1alpha2.Fabric, conditionType, message string) {
+	meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: instance.Generation,
+		Reason:             operatorv1alpha2.ReasonSucceeded,
+		Message:            message,
+	})
+}
+
+// markStageFailed records a failed reconciliation stage on the fabric status
+func markStageFailed(instance *operatorv1alpha2.Fabric, conditionType, reason string, err error) {
+	meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: instance.Generation,
+		Reason:             reason,
+		Message:            err.Error(),
+	})
+}
+
+// clearStage removes the condition of a stage which does not apply to the fabric
+func clearStage(instance *operatorv1alpha2.Fabric, conditionType string) {
+	meta.RemoveStatusCondition(&instance.Status.Conditions, conditionType)
+}
@@ -0,0 +1,225 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	operatorv1alpha1 "github.com/DanielXLee/cluster-fabric-operator/api/v1alpha1"
+	operatorv1alpha2 "github.com/DanielXLee/cluster-fabric-operator/api/v1alpha2"
+	consts "github.com/DanielXLee/cluster-fabric-operator/controllers/ensures"
+	"github.com/DanielXLee/cluster-fabric-operator/controllers/ensures/broker"
+	"github.com/DanielXLee/cluster-fabric-operator/controllers/ensures/gateway"
+	"github.com/DanielXLee/cluster-fabric-operator/controllers/ensures/operator/brokercr"
+	"github.com/DanielXLee/cluster-fabric-operator/controllers/ensures/operator/servicediscoverycr"
+	"github.com/DanielXLee/cluster-fabric-operator/controllers/ensures/operator/submarinercr"
+	"github.com/DanielXLee/cluster-fabric-operator/controllers/ensures/operator/submarinerop"
+)
+
+// FinalizeFabric tears down everything the fabric created, then releases the fabric finalizer
+func (r *FabricReconciler) FinalizeFabric(instance *operatorv1alpha2.Fabric) error {
+	if !controllerutil.ContainsFinalizer(instance, consts.FabricFinalizer) {
+		return nil
+	}
+
+	sharedOperator, err := isOperatorShared(context.TODO(), r.Client, instance)
+	if err != nil {
+		return err
+	}
+	r.sharedOperator = sharedOperator
+	if err := r.UninstallFabric(instance); err != nil {
+		return err
+	}
+
+	controllerutil.RemoveFinalizer(instance, consts.FabricFinalizer)
+	return r.Client.Update(context.TODO(), instance)
+}
+
+// UninstallFabric tears down what the fabric deployed and joined, as the roles of the reconciler say
+func (r *FabricReconciler) UninstallFabric(instance *operatorv1alpha2.Fabric) error {
+	if r.JoinBroker {
+		klog.Info("Uninstall submariner from managed cluster")
+		if err := r.UninstallSubmarinerCluster(instance); err != nil {
+			return err
+		}
+	}
+
+	if r.DeployBroker {
+		klog.Info("Uninstall submariner from managed clusters")
+		if err := r.UninstallManagedClusters(instance); err != nil {
+			return err
+		}
+
+		klog.Info("Uninstall submariner broker")
+		if err := r.UninstallSubmarinerBroker(instance); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UninstallSubmarinerCluster reverts what JoinSubmarinerCluster did on the managed cluster and on the broker
+func (r *FabricReconciler) UninstallSubmarinerCluster(instance *operatorv1alpha2.Fabric) error {
+	clusterID := instance.Spec.JoinConfig.ClusterID
+
+	klog.Info("Deleting Submariner")
+	if err := submarinercr.Delete(r.Client, consts.SubmarinerOperatorNamespace); err != nil {
+		klog.Errorf("Error deleting submariner: %v", err)
+		return err
+	}
+
+	klog.Info("Deleting service discovery")
+	if err := servicediscoverycr.Delete(r.Client, consts.SubmarinerOperatorNamespace); err != nil {
+		klog.Errorf("Error deleting service discovery: %v", err)
+		return err
+	}
+
+	klog.Info("Deleting the gateway load balancer")
+	if err := gateway.DeleteLoadBalancer(r.Client, consts.SubmarinerOperatorNamespace); err != nil {
+		klog.Errorf("Error deleting the gateway load balancer: %v", err)
+		return err
+	}
+
+	// The broker shares the operator with the managed cluster, leave it to the broker uninstall
+	if !r.DeployBroker && !r.sharedOperator {
+		klog.Info("Deleting the Submariner operator")
+		if err := submarinerop.Uninstall(r.Client); err != nil {
+			klog.Errorf("Error deleting the operator: %v", err)
+			return err
+		}
+	}
+
+	brokerInfo, err := r.GetBrokerInfo(instance)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			klog.Info("Broker info not found, skip cleaning up the broker")
+			return nil
+		}
+		return err
+	}
+	brokerCluster, err := brokerInfo.GetBrokerAdministratorCluster()
+	if err != nil {
+		klog.Errorf("unable to get broker cluster client: %v", err)
+		return err
+	}
+	brokerNamespace := string(brokerInfo.ClientToken.Data["namespace"])
+
+	if brokerInfo.IsGlobalnetEnabled() {
+		klog.Info("Releasing the global CIDR of the cluster")
+		if err := r.ReleaseGlobalCIDR(brokerCluster.GetClient(), brokerCluster.GetAPIReader(), brokerNamespace, clusterID,
+			r.allocationOwner(instance)); err != nil {
+			klog.Errorf("Error releasing the global CIDR: %v", err)
+			return err
+		}
+	}
+
+	klog.Info("Deleting SA for cluster")
+	if err := broker.DeleteSAForCluster(brokerCluster.GetClient(), clusterID); err != nil {
+		klog.Errorf("Error deleting SA for cluster: %v", err)
+		return err
+	}
+	return nil
+}
+
+// UninstallSubmarinerBroker reverts what DeploySubmerinerBroker did on the broker cluster
+func (r *FabricReconciler) UninstallSubmarinerBroker(instance *operatorv1alpha2.Fabric) error {
+	klog.Info("Deleting the broker")
+	if err := brokercr.Delete(r.Client); err != nil {
+		klog.Errorf("Error deleting the broker: %v", err)
+		return err
+	}
+
+	// The cluster joined by a cluster join of its own still runs the operator, leave it to the join uninstall
+	if !r.sharedOperator {
+		klog.Info("Deleting the Submariner operator")
+		if err := submarinerop.Uninstall(r.Client); err != nil {
+			klog.Errorf("Error deleting the operator: %v", err)
+			return err
+		}
+	}
+
+	// The broker namespace holds the broker info, the globalnet info, and all the broker service accounts
+	klog.Infof("Deleting the broker namespace %s", consts.SubmarinerBrokerNamespace)
+	if err := broker.DeleteBrokerNamespace(r.Client); err != nil {
+		klog.Errorf("Error deleting the broker namespace: %v", err)
+		return err
+	}
+	return nil
+}
+
+// isOperatorShared reports whether the submariner operator of the cluster is still used by a fabric, a broker or a
+// cluster join other than the one uninstalled, which is neither being deleted nor migrated
+func isOperatorShared(ctx context.Context, c client.Client, instance client.Object) (bool, error) {
+	fabrics := &operatorv1alpha2.FabricList{}
+	if err := c.List(ctx, fabrics); err != nil {
+		return false, err
+	}
+	brokers := &operatorv1alpha2.BrokerList{}
+	if err := c.List(ctx, brokers); err != nil {
+		return false, err
+	}
+	clusterJoins := &operatorv1alpha2.ClusterJoinList{}
+	if err := c.List(ctx, clusterJoins); err != nil {
+		return false, err
+	}
+
+	var objects []client.Object
+	for i := range fabrics.Items {
+		if _, migrated := fabrics.Items[i].GetAnnotations()[consts.FabricMigratedAnnotation]; !migrated {
+			objects = append(objects, &fabrics.Items[i])
+		}
+	}
+	for i := range brokers.Items {
+		objects = append(objects, &brokers.Items[i])
+	}
+	for i := range clusterJoins.Items {
+		objects = append(objects, &clusterJoins.Items[i])
+	}
+	for _, obj := range objects {
+		if obj.GetUID() != instance.GetUID() && obj.GetDeletionTimestamp().IsZero() {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ReleaseGlobalCIDR releases the global CIDR of the cluster on the broker, its GlobalCIDRAllocation or its globalnet
+// configmap entry is reclaimed once the release grace period is over
+func (r *FabricReconciler) ReleaseGlobalCIDR(c client.Client, reader client.Reader, brokerNamespace, clusterID string,
+	owner *operatorv1alpha1.AllocationOwner) error {
+	if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		return broker.ReleaseGlobalCIDRs(c, reader, brokerNamespace, clusterID, owner)
+	}); err != nil {
+		return err
+	}
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		globalnetConfigMap, err := broker.GetGlobalnetConfigMap(reader, brokerNamespace)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				return nil
+			}
+			return err
+		}
+		return broker.ReleaseClusterInGlobalnetConfigMap(c, globalnetConfigMap, clusterID)
+	})
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	submariner "github.com/submariner-io/submariner-operator/apis/submariner/v1alpha1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	operatorv1alpha2 "github.com/DanielXLee/cluster-fabric-operator/api/v1alpha2"
+	consts "github.com/DanielXLee/cluster-fabric-operator/controllers/ensures"
+)
+
+// noSubmarinerCRDsClient answers as an API server the Submariner CRDs were never installed on
+type noSubmarinerCRDsClient struct {
+	client.Client
+}
+
+func (c *noSubmarinerCRDsClient) noMatch(obj runtime.Object) error {
+	switch obj.(type) {
+	case *submariner.Submariner, *submariner.ServiceDiscovery, *submariner.Broker:
+		return &meta.NoKindMatchError{GroupKind: schema.GroupKind{Group: submariner.SchemeGroupVersion.Group}}
+	}
+	return nil
+}
+
+func (c *noSubmarinerCRDsClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	if err := c.noMatch(obj); err != nil {
+		return err
+	}
+	return c.Client.Get(ctx, key, obj)
+}
+
+func (c *noSubmarinerCRDsClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	if err := c.noMatch(obj); err != nil {
+		return err
+	}
+	return c.Client.Delete(ctx, obj, opts...)
+}
+
+var _ = Describe("Fabric uninstall", func() {
+	It("Should finalize a fabric whose Submariner CRDs were never installed", func() {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(apiextensionsv1.AddToScheme(scheme)).To(Succeed())
+		Expect(operatorv1alpha2.AddToScheme(scheme)).To(Succeed())
+		fabric := &operatorv1alpha2.Fabric{
+			ObjectMeta: metav1.ObjectMeta{Name: "fabric", Namespace: "default", Finalizers: []string{consts.FabricFinalizer}},
+			Spec: operatorv1alpha2.FabricSpec{
+				BrokerConfig: &operatorv1alpha2.BrokerConfig{},
+				JoinConfig:   operatorv1alpha2.JoinConfig{ClusterID: "cluster1"},
+			},
+		}
+		c := &noSubmarinerCRDsClient{Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(fabric).Build()}
+		r := &FabricReconciler{Client: c, Reader: c, Scheme: scheme, DeployBroker: true, JoinBroker: true}
+
+		Expect(c.Get(context.TODO(), client.ObjectKeyFromObject(fabric), fabric)).To(Succeed())
+		Expect(r.FinalizeFabric(fabric)).To(Succeed())
+
+		finalized := &operatorv1alpha2.Fabric{}
+		Expect(c.Get(context.TODO(), client.ObjectKeyFromObject(fabric), finalized)).To(Succeed())
+		Expect(finalized.GetFinalizers()).NotTo(ContainElement(consts.FabricFinalizer))
+	})
+})
@@ -6,6 +6,8 @@ require (
 	github.com/onsi/ginkgo v1.16.1
 	github.com/onsi/gomega v1.11.0
 	github.com/pkg/errors v0.9.1
+	github.com/prometheus/client_golang v1.10.0
+	github.com/submariner-io/submariner v0.9.1
 	github.com/submariner-io/submariner-operator v0.9.1
 	k8s.io/api v0.20.2
 	k8s.io/apiextensions-apiserver v0.20.1
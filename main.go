@@ -23,6 +23,7 @@ import (
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	submariner "github.com/submariner-io/submariner-operator/apis/submariner/v1alpha1"
+	submarinerv1 "github.com/submariner-io/submariner/pkg/apis/submariner.io/v1"
 	apiextensions "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
@@ -33,6 +34,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 
 	operatorv1alpha1 "github.com/DanielXLee/cluster-fabric-operator/api/v1alpha1"
+	operatorv1alpha2 "github.com/DanielXLee/cluster-fabric-operator/api/v1alpha2"
 	"github.com/DanielXLee/cluster-fabric-operator/controllers"
 	//+kubebuilder:scaffold:imports
 )
@@ -45,8 +47,10 @@ func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 	utilruntime.Must(apiextensions.AddToScheme(scheme))
 	utilruntime.Must(submariner.AddToScheme(scheme))
+	utilruntime.Must(submarinerv1.AddToScheme(scheme))
 
 	utilruntime.Must(operatorv1alpha1.AddToScheme(scheme))
+	utilruntime.Must(operatorv1alpha2.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 }
 
@@ -56,14 +60,19 @@ func main() {
 	var probeAddr string
 	var deployBroker bool
 	var joinBroker bool
+	var migrateFabrics bool
 
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
-	flag.BoolVar(&deployBroker, "deploy-broker", false, "Enable deploy broker for controller manager. ")
-	flag.BoolVar(&joinBroker, "join-broker", false, "Enable join managed to broker for controller manager. ")
+	flag.BoolVar(&deployBroker, "deploy-broker", false,
+		"Permit the fabrics to deploy the broker. Every role is permitted when neither this nor --join-broker is set.")
+	flag.BoolVar(&joinBroker, "join-broker", false,
+		"Permit the fabrics to join the cluster to the broker. Every role is permitted when neither this nor --deploy-broker is set.")
+	flag.BoolVar(&migrateFabrics, "migrate-fabrics", false,
+		"Migrate the fabrics to brokers and cluster joins, for the roles they request and are permitted.")
 
 	klog.InitFlags(nil)
 	defer klog.Flush()
@@ -83,6 +92,18 @@ func main() {
 	}
 
 	if err = (&controllers.FabricReconciler{
+		Client:         mgr.GetClient(),
+		Reader:         mgr.GetAPIReader(),
+		Config:         mgr.GetConfig(),
+		Scheme:         mgr.GetScheme(),
+		DeployBroker:   deployBroker,
+		JoinBroker:     joinBroker,
+		MigrateFabrics: migrateFabrics,
+	}).SetupWithManager(mgr); err != nil {
+		klog.Errorf("unable to create controller Fabric: %v", err)
+		os.Exit(1)
+	}
+	if err = (&controllers.BrokerReconciler{
 		Client:       mgr.GetClient(),
 		Reader:       mgr.GetAPIReader(),
 		Config:       mgr.GetConfig(),
@@ -90,9 +111,41 @@ func main() {
 		DeployBroker: deployBroker,
 		JoinBroker:   joinBroker,
 	}).SetupWithManager(mgr); err != nil {
-		klog.Errorf("unable to create controller Fabric: %v", err)
+		klog.Errorf("unable to create controller Broker: %v", err)
 		os.Exit(1)
 	}
+	if err = (&controllers.ClusterJoinReconciler{
+		Client:       mgr.GetClient(),
+		Reader:       mgr.GetAPIReader(),
+		Config:       mgr.GetConfig(),
+		Scheme:       mgr.GetScheme(),
+		DeployBroker: deployBroker,
+		JoinBroker:   joinBroker,
+	}).SetupWithManager(mgr); err != nil {
+		klog.Errorf("unable to create controller ClusterJoin: %v", err)
+		os.Exit(1)
+	}
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err = (&operatorv1alpha2.Fabric{}).SetupWebhookWithManager(mgr); err != nil {
+			klog.Errorf("unable to create webhook Fabric: %v", err)
+			os.Exit(1)
+		}
+		if err = (&operatorv1alpha2.Broker{}).SetupWebhookWithManager(mgr); err != nil {
+			klog.Errorf("unable to create webhook Broker: %v", err)
+			os.Exit(1)
+		}
+		if err = (&operatorv1alpha2.ClusterJoin{}).SetupWebhookWithManager(mgr); err != nil {
+			klog.Errorf("unable to create webhook ClusterJoin: %v", err)
+			os.Exit(1)
+		}
+		if err = (&controllers.FabricDefaulter{
+			Client: mgr.GetClient(),
+			Scheme: mgr.GetScheme(),
+		}).SetupWebhookWithManager(mgr); err != nil {
+			klog.Errorf("unable to create defaulting webhook Fabric: %v", err)
+			os.Exit(1)
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {